@@ -0,0 +1,178 @@
+package cfg
+
+import (
+	"strings"
+)
+
+// envVar a variable of the environment or of a .env file, line and column are 0 when unknown
+type envVar struct {
+	key    string
+	value  string
+	line   int
+	column int
+	null   bool // removes the key (Ex. "--key=null")
+}
+
+// varBatch variables loaded together (Ex. a .env file), see loadVars
+type varBatch struct {
+	vars   []envVar
+	origin Origin
+}
+
+// parseDotEnv parses the content of a .env file, compatible with https://github.com/joho/godotenv
+//
+//	# comment
+//	export KEY=value          # "export" is optional, inline comments need a space before "#"
+//	KEY: value                # ":" is accepted as separator
+//	DOUBLE="line 1\nline 2"   # escapes \n \r \t \" \\ \$, can span multiple lines
+//	SINGLE='${literal}'       # no escapes nor interpolation, can span multiple lines
+//	URL=http://${HOST}:$PORT  # unquoted and double-quoted values are interpolated, like any config value
+//
+// Literal dollar signs (single-quoted values and \$) are escaped as "$$" in the values.
+func parseDotEnv(content []byte) ([]envVar, error) {
+	var vars []envVar
+	src := string(content)
+	pos := 0
+
+	errorf := func(offset int, msg string, args ...any) error {
+		return syntaxError("dotenv", content, offset, msg, args...)
+	}
+	// skipSpace skips spaces and tabs (and \r of CRLF)
+	skipSpace := func() {
+		for pos < len(src) && (src[pos] == ' ' || src[pos] == '\t' || src[pos] == '\r') {
+			pos++
+		}
+	}
+	// endOfLine expects nothing but a comment until the end of the line
+	endOfLine := func() error {
+		skipSpace()
+		if pos < len(src) && src[pos] == '#' {
+			for pos < len(src) && src[pos] != '\n' {
+				pos++
+			}
+		}
+		if pos < len(src) && src[pos] != '\n' {
+			return errorf(pos, "unexpected %q after the value", src[pos])
+		}
+		pos++
+		return nil
+	}
+
+	for pos < len(src) {
+		skipSpace()
+		if pos >= len(src) {
+			break
+		}
+		if src[pos] == '\n' || src[pos] == '#' {
+			if err := endOfLine(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if strings.HasPrefix(src[pos:], "export ") || strings.HasPrefix(src[pos:], "export\t") {
+			pos += len("export")
+			skipSpace()
+		}
+
+		// key
+		start := pos
+		for pos < len(src) && strings.IndexByte("=: \t\r\n#", src[pos]) < 0 {
+			pos++
+		}
+		key := src[start:pos]
+		if key == "" {
+			return nil, errorf(start, "expected a variable name, found %q", src[pos])
+		}
+		if strings.ContainsAny(key, `"'$`+"`") {
+			return nil, errorf(start, "invalid variable name %q", key)
+		}
+		skipSpace()
+		if pos >= len(src) || (src[pos] != '=' && src[pos] != ':') {
+			return nil, errorf(start, "expected '=' after the variable %q", key)
+		}
+		pos++
+		skipSpace()
+
+		v := envVar{key: key}
+		v.line, v.column = lineColumn(content, start)
+
+		// value
+		switch quote := byte(0); {
+		case pos < len(src) && (src[pos] == '"' || src[pos] == '\'' || src[pos] == '`'):
+			quote = src[pos]
+			open := pos
+			pos++
+
+			var sb strings.Builder
+			for ; pos < len(src) && src[pos] != quote; pos++ {
+				c := src[pos]
+				if c == '\r' && pos+1 < len(src) && src[pos+1] == '\n' {
+					// CRLF inside multi-line values
+					continue
+				}
+				if quote != '"' {
+					if c == '$' {
+						sb.WriteByte('$')
+					}
+					sb.WriteByte(c)
+					continue
+				}
+				if c != '\\' || pos+1 == len(src) {
+					sb.WriteByte(c)
+					continue
+				}
+				pos++
+				switch src[pos] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				case '"', '\\':
+					sb.WriteByte(src[pos])
+				case '$':
+					sb.WriteString("$$")
+				default:
+					sb.WriteByte('\\')
+					sb.WriteByte(src[pos])
+				}
+			}
+			if pos >= len(src) {
+				return nil, errorf(open, "unterminated quoted value of the variable %q", key)
+			}
+			pos++
+			v.value = sb.String()
+		default:
+			start := pos
+			for pos < len(src) && src[pos] != '\n' {
+				if src[pos] == '#' && (src[pos-1] == ' ' || src[pos-1] == '\t') {
+					break
+				}
+				pos++
+			}
+			v.value = strings.TrimRight(src[start:pos], " \t\r")
+		}
+
+		if err := endOfLine(); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// lineColumn line and column (starting at 1) of the offset in the content
+func lineColumn(content []byte, offset int) (line, column int) {
+	line, column = 1, 1
+	for _, b := range content[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package cfg
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := "# comment\r\n" +
+		"\r\n" +
+		"PLAIN=value\r\n" +
+		"export EXPORTED = exported value # comment\r\n" +
+		"  INDENTED: yaml style\n" +
+		"HASH=a#b\n" +
+		"EMPTY=\n" +
+		"EMPTY_COMMENT= # comment\n" +
+		"DOUBLE=\"line 1\\nline 2 \\\"quoted\\\" \\$HOME # not a comment\"  # comment\n" +
+		"SINGLE='${literal} \\n'\n" +
+		"MULTI=\"first\r\nsecond\"\n" +
+		"BACKTICK=`it's`\n" +
+		"URL=http://${HOST}:$PORT\n" +
+		"db.host=localhost"
+
+	got, err := parseDotEnv([]byte(content))
+	if err != nil {
+		t.Fatalf("parseDotEnv() error = %v", err)
+	}
+	want := []envVar{
+		{key: "PLAIN", value: "value", line: 3, column: 1},
+		{key: "EXPORTED", value: "exported value", line: 4, column: 8},
+		{key: "INDENTED", value: "yaml style", line: 5, column: 3},
+		{key: "HASH", value: "a#b", line: 6, column: 1},
+		{key: "EMPTY", value: "", line: 7, column: 1},
+		{key: "EMPTY_COMMENT", value: "", line: 8, column: 1},
+		{key: "DOUBLE", value: "line 1\nline 2 \"quoted\" $$HOME # not a comment", line: 9, column: 1},
+		{key: "SINGLE", value: "$${literal} \\n", line: 10, column: 1},
+		{key: "MULTI", value: "first\nsecond", line: 11, column: 1},
+		{key: "BACKTICK", value: "it's", line: 13, column: 1},
+		{key: "URL", value: "http://${HOST}:$PORT", line: 14, column: 1},
+		{key: "db.host", value: "localhost", line: 15, column: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDotEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDotEnv_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"no separator", "A=1\nINVALID\n", 2},
+		{"unterminated", "A=1\nB=\"open\nC=2\n", 2},
+		{"after quote", "A='a' b\n", 1},
+		{"invalid name", "A=1\n\n'B'=2\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDotEnv([]byte(tt.content))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("parseDotEnv() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("parseDotEnv() line = %v, want %v (%v)", syntaxErr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestEnv_LoadDotEnv(t *testing.T) {
+	env := New(O{"HOST": "localhost"})
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		".env": {Data: []byte("PORT=8080\nURL=\"http://${HOST}:${PORT}\"\nPRICE='$5'\nTOTAL=\"\\$10 for ${HOST}\"\nserver.port=80\n")},
+	}))
+	if err := env.LoadDotEnv(); err != nil {
+		t.Fatalf("LoadDotEnv() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"URL", "http://localhost:8080"},
+		{"PRICE", "$5"},
+		{"TOTAL", "$10 for localhost"},
+		{"server.port", "80"},
+	}
+	for _, tt := range tests {
+		if got := env.String(tt.key); got != tt.want {
+			t.Errorf("String(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	if got, want := env.Origin("URL"), []Origin{{Source: SourceDotEnv, Path: ".env", Line: 2, Column: 1, Order: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %v, want %v", got, want)
+	}
+
+	env.SetFileSystem(http.FS(fstest.MapFS{".env": {Data: []byte("A=1\nB=\"open\n")}}))
+	if err := env.LoadDotEnv(); err == nil {
+		t.Errorf("LoadDotEnv() must fail")
+	}
+}
+
+func TestEnv_Load_DotEnvFiles(t *testing.T) {
+	files := fstest.MapFS{
+		"config.json":        {Data: []byte(`{"profiles": "dev", "a": "config"}`)},
+		".env":               {Data: []byte("a=env\nb=env\nc=env\nd=env\n")},
+		".env.local":         {Data: []byte("b=local\nc=local\nd=local\n")},
+		".env.dev":           {Data: []byte("c=dev\nd=dev\n")},
+		".env.dev.local":     {Data: []byte("d=dev.local\n")},
+		".env.prod":          {Data: []byte("c=prod\n")},
+		".env.prod.local":    {Data: []byte("d=prod.local\n")},
+		"config-dev.json":    {Data: []byte(`{"a": "config-dev"}`)},
+		"custom.env":         {Data: []byte("a=custom\n")},
+		"custom.dev.env":     {Data: []byte("b=custom.dev\n")},
+		"profile/.env":       {Data: []byte("profiles=prod\n")},
+		"profile/.env.prod":  {Data: []byte("c=prod\n")},
+		"profile/.env.local": {Data: []byte("d=local\n")},
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  map[string]string
+	}{
+		{"default", nil, map[string]string{"a": "env", "b": "local", "c": "dev", "d": "dev.local"}},
+		{"custom", []string{"custom.env", "custom.{profile}.env"}, map[string]string{"a": "custom", "b": "custom.dev", "c": "", "d": ""}},
+		{"profile defined by .env", []string{"profile/.env", "profile/.env.local", "profile/.env.{profile}"}, map[string]string{"profiles": "prod", "c": "prod", "d": "local"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := New()
+			env.SetFileSystem(http.FS(files))
+			if tt.files != nil {
+				env.SetDotEnvFiles(tt.files...)
+			}
+			if err := env.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got := env.String(key); got != want {
+					t.Errorf("String(%q) = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
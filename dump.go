@@ -0,0 +1,157 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// DumpFormat output format of Dump
+type DumpFormat string
+
+const (
+	DumpTable DumpFormat = "table" // aligned columns (default)
+	DumpJSON  DumpFormat = "json"  // array of DumpEntry
+)
+
+// MaskedValue replaces masked values in Dump
+const MaskedValue = "******"
+
+// DumpOptions options of Dump
+type DumpOptions struct {
+	Prefix string     // only keys equal to or under the prefix
+	Format DumpFormat // DumpTable (default) or DumpJSON
+	Mask   []string   // glob patterns of keys whose values are masked (see MatchKey), in addition to MarkSensitive
+}
+
+// DumpEntry a leaf of the effective config
+type DumpEntry struct {
+	Key     string   `json:"key"`
+	Value   any      `json:"value"`
+	Expr    string   `json:"expr,omitempty"`
+	Kind    string   `json:"kind"`
+	Origins []Origin `json:"origins,omitempty"`
+}
+
+// Dump prints every leaf key of the effective config, sorted, with its value, the raw
+// expression before expansion, its kind and its source. A whole reference to an object or
+// array (Ex. "${db}") is a single key, with the expanded value and its expression.
+func (c *Env) Dump(w io.Writer, opts DumpOptions) error {
+	entries := c.dumpEntries(opts)
+
+	if opts.Format == DumpJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	} else if opts.Format != "" && opts.Format != DumpTable {
+		return fmt.Errorf("cfg: unknown dump format %q", opts.Format)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tEXPRESSION\tKIND\tSOURCE")
+	for _, e := range entries {
+		source := ""
+		if len(e.Origins) > 0 {
+			source = e.Origins[0].String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Key, c.toString(e.Key, e.Value), e.Expr, e.Kind, source)
+	}
+	return tw.Flush()
+}
+
+// Explain describes the effective value of the key and all its sources
+func (c *Env) Explain(key string) string {
+	entries := c.dumpEntries(DumpOptions{Prefix: key})
+	if len(entries) == 0 {
+		return key + ": not found"
+	}
+
+	var sb strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "%s = %s (%s)\n", e.Key, c.toString(e.Key, e.Value), e.Kind)
+		if e.Expr != "" {
+			fmt.Fprintf(&sb, "  expression: %s\n", e.Expr)
+		}
+		for i, origin := range e.Origins {
+			if i == 0 {
+				fmt.Fprintf(&sb, "  source: %s\n", origin)
+			} else {
+				fmt.Fprintf(&sb, "  overrides: %s\n", origin)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (c *Env) dumpEntries(opts DumpOptions) []*DumpEntry {
+	unlock := c.lock(false)
+	defer unlock()
+
+	c.expand(c.root, "")
+
+	var entries []*DumpEntry
+	dumpKeys("", c.root, func(key string, e *Entry) {
+		if !hasKeyPrefix(key, opts.Prefix) && !(e.expr != "" && hasKeyPrefix(opts.Prefix, key)) {
+			return
+		}
+		entry := &DumpEntry{
+			Key:     key,
+			Value:   e.Value(),
+			Expr:    e.expr,
+			Kind:    e.kind.String(),
+			Origins: append([]Origin{}, e.origins...),
+		}
+		if e.kind == ArrayKind || e.kind == ObjectKind {
+			// whole reference, the sensitive keys of the value are masked
+			entry.Value = c.marshalValue(key, e, &marshalOptions{redacted: true, mask: opts.Mask})
+		}
+		masked := c.IsSensitive(key)
+		for _, pattern := range opts.Mask {
+			masked = masked || MatchKey(pattern, key)
+		}
+		if masked {
+			entry.Value = MaskedValue
+			if entry.Expr != "" {
+				entry.Expr = MaskedValue
+			}
+		}
+		entries = append(entries, entry)
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// dumpKeys same as Entry.walkKeys, a whole reference (Ex. "${db}") is visited as a leaf
+func dumpKeys(prefix string, e *Entry, visit func(key string, e *Entry)) {
+	if e.expr != "" {
+		visit(prefix, e)
+		return
+	}
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		if len(list) == 0 && prefix != "" {
+			visit(prefix, e)
+		}
+		for i, entry := range list {
+			dumpKeys(prefix+"["+strconv.Itoa(i)+"]", entry, visit)
+		}
+	case ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		if len(value) == 0 && prefix != "" {
+			visit(prefix, e)
+		}
+		for key, entry := range value {
+			dumpKeys(joinKey(prefix, Escape(key)), entry, visit)
+		}
+	default:
+		visit(prefix, e)
+	}
+}
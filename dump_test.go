@@ -0,0 +1,85 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnv_Dump(t *testing.T) {
+	env := New(O{
+		"app": O{"name": "My App", "title": "${app.name}!"},
+		"db":  O{"host": "localhost", "password": "secret", "ports": []int{1, 2}},
+	})
+	env.Set("db.host", "db")
+
+	var buf bytes.Buffer
+	if err := env.Dump(&buf, DumpOptions{Mask: []string{"**.password"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"KEY          VALUE    EXPRESSION    KIND    SOURCE",
+		"app.name     My App                 string  default",
+		"app.title    My App!  ${app.name}!  string  default",
+		"db.host      db                     string  set",
+		"db.password  ******                 string  default",
+		"db.ports[0]  1                      number  default",
+		"db.ports[1]  2                      number  default",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("Dump() = \n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := env.Dump(&buf, DumpOptions{Prefix: "db.host", Format: DumpJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var got []DumpEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := []DumpEntry{{
+		Key:   "db.host",
+		Value: "db",
+		Kind:  "string",
+		Origins: []Origin{
+			{Source: SourceSet, Order: 2},
+			{Source: SourceDefault, Order: 1},
+		},
+	}}
+	if !reflect.DeepEqual(got, wantJSON) {
+		t.Errorf("Dump() = %+v, want %+v", got, wantJSON)
+	}
+
+	// a whole reference is a single key, with its expression and the masked value
+	env.Set("backup", "${db}")
+	env.String("backup.host")
+	buf.Reset()
+	if err := env.Dump(&buf, DumpOptions{Prefix: "backup", Mask: []string{"**.password"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `backup  {"host":"db","password":"******","ports":[1,2]}  ${db}`; !strings.Contains(got, want) {
+		t.Errorf("Dump() = \n%s\nwant\n%s", got, want)
+	}
+	if got := env.Explain("backup.host"); !strings.Contains(got, "expression: ${db}") {
+		t.Errorf("Explain() = %s", got)
+	}
+
+	if err := env.Dump(&buf, DumpOptions{Format: "xml"}); err == nil {
+		t.Errorf("Dump() expected error for unknown format")
+	}
+}
+
+func TestEnv_Explain(t *testing.T) {
+	env := New(O{"db": O{"host": "localhost"}})
+	env.Set("db.host", "db")
+
+	want := "db.host = db (string)\n  source: set\n  overrides: default\n"
+	if got := env.Explain("db.host"); got != want {
+		t.Errorf("Explain() = %q, want %q", got, want)
+	}
+}
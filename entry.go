@@ -1,5 +1,11 @@
 package cfg
 
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // EntryKind represents the data types supported in the
 // configuration, in order to maintain full compatibility
 // with JSON files
@@ -11,6 +17,7 @@ const (
 	StringKind                  // string, for JSON strings
 	ArrayKind                   // []*Entry{}, for JSON arrays
 	ObjectKind                  // map[string]*Entry{}, for JSON objects
+	NullKind                    // nil, for JSON null, removes the inherited value when merged
 )
 
 // Entry all properties are mapped to a data type below,
@@ -19,6 +26,30 @@ type Entry struct {
 	kind  EntryKind // Value typing
 	value any       // Saved value (bool, float64, string, []*Entry, map[string]*Entry)
 	expr  string    // When string with expression (${var} | $var)
+
+	expanded bool  // value is the expansion of expr, see Env.expand
+	exprErr  error // error of the expansion of expr
+
+	origins []Origin // Sources of the value, the first is the effective one
+}
+
+func (k EntryKind) String() string {
+	switch k {
+	case BoolKind:
+		return "bool"
+	case NumberKind:
+		return "number"
+	case StringKind:
+		return "string"
+	case ArrayKind:
+		return "array"
+	case ObjectKind:
+		return "object"
+	case NullKind:
+		return "null"
+	default:
+		return "EntryKind(" + strconv.Itoa(int(k)) + ")"
+	}
 }
 
 func (e *Entry) Kind() EntryKind {
@@ -27,7 +58,7 @@ func (e *Entry) Kind() EntryKind {
 
 func (e *Entry) Value() any {
 	switch e.kind {
-	case BoolKind, StringKind, NumberKind:
+	case BoolKind, StringKind, NumberKind, NullKind:
 		return e.value
 	case ArrayKind:
 		var list []any
@@ -38,7 +69,7 @@ func (e *Entry) Value() any {
 		return list
 	default:
 		obj := map[string]any{}
-		value := e.value.(map[string]*Entry)
+		value, _ := e.value.(map[string]*Entry)
 		for key, entry := range value {
 			obj[key] = entry.Value()
 		}
@@ -48,34 +79,40 @@ func (e *Entry) Value() any {
 
 // Merge merges two objects
 func (e *Entry) Merge(other *Entry) {
-	switch other.kind {
-	case BoolKind, StringKind, NumberKind, ArrayKind:
-		e.value = other.value
+	(&merger{}).merge(e, other, "")
+}
+
+// dropNulls removes the null properties and elements (nested), a null is only meaningful when merged
+func (e *Entry) dropNulls() *Entry {
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		var value []*Entry
+		for _, entry := range list {
+			if entry.kind != NullKind {
+				value = append(value, entry.dropNulls())
+			}
+		}
+		e.value = value
 	case ObjectKind:
-		if e.value == nil || e.kind != ObjectKind {
-			e.value = map[string]*Entry{}
-		}
-		e.kind = ObjectKind
-
-		target := e.value.(map[string]*Entry)
-		source := other.value.(map[string]*Entry)
-		for key, src := range source {
-			if dest, exist := target[key]; !exist {
-				target[key] = src
-			} else if dest == nil || src.kind != dest.kind {
-				target[key] = src
-			} else if src.value == nil {
-				delete(target, key)
+		obj, _ := e.value.(map[string]*Entry)
+		for key, entry := range obj {
+			if entry.kind == NullKind {
+				delete(obj, key)
 			} else {
-				dest.Merge(src)
+				entry.dropNulls()
 			}
 		}
 	}
+	return e
 }
 
 // Clone makes a deep copy of the entry
 func (e *Entry) Clone() *Entry {
-	other := &Entry{kind: e.kind, value: e.value, expr: e.expr}
+	other := &Entry{kind: e.kind, value: e.value, expr: e.expr, expanded: e.expanded, exprErr: e.exprErr}
+	if e.origins != nil {
+		other.origins = append([]Origin{}, e.origins...)
+	}
 
 	switch other.kind {
 	case ArrayKind:
@@ -114,3 +151,164 @@ func (e *Entry) Walk(visitor func(*Entry)) {
 		}
 	}
 }
+
+// maxOrigins the maximum number of origins of an entry, the oldest overridden origins are dropped
+const maxOrigins = 8
+
+// mergeOrigins the winning origins followed by the overridden ones. An overridden origin with the same source and
+// path as a newer one (Ex. a key changed by Set multiple times) is dropped.
+func mergeOrigins(winning, overridden []Origin) []Origin {
+	if len(overridden) == 0 {
+		return winning
+	}
+	out := make([]Origin, 0, len(winning)+len(overridden))
+	for _, list := range [][]Origin{winning, overridden} {
+		for _, origin := range list {
+			if len(out) < maxOrigins && !hasOrigin(out, origin) {
+				out = append(out, origin)
+			}
+		}
+	}
+	return out
+}
+
+// hasOrigin checks if the list has an origin with the same source and path
+func hasOrigin(list []Origin, origin Origin) bool {
+	for _, o := range list {
+		if o.Source == origin.Source && o.Path == origin.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// walkKeys visits all leaves (values, empty arrays and empty objects) with their dotted keys
+func (e *Entry) walkKeys(prefix string, visit func(key string, e *Entry)) {
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		if len(list) == 0 && prefix != "" {
+			visit(prefix, e)
+		}
+		for i, entry := range list {
+			entry.walkKeys(prefix+"["+strconv.Itoa(i)+"]", visit)
+		}
+	case ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		if len(value) == 0 && prefix != "" {
+			visit(prefix, e)
+		}
+		for key, entry := range value {
+			entry.walkKeys(joinKey(prefix, Escape(key)), visit)
+		}
+	default:
+		visit(prefix, e)
+	}
+}
+
+// rawValue value of the entry without expression expansion
+func (e *Entry) rawValue() any {
+	if e.expr != "" {
+		return e.expr
+	}
+	switch e.kind {
+	case BoolKind, StringKind, NumberKind, NullKind:
+		return e.value
+	case ArrayKind:
+		list := []any{}
+		value, _ := e.value.([]*Entry)
+		for _, entry := range value {
+			list = append(list, entry.rawValue())
+		}
+		return list
+	default:
+		obj := map[string]any{}
+		value, _ := e.value.(map[string]*Entry)
+		for key, entry := range value {
+			obj[key] = entry.rawValue()
+		}
+		return obj
+	}
+}
+
+// resetExpressions restores the expressions, they will be expanded again on access
+func (e *Entry) resetExpressions() {
+	if e.expr != "" {
+		e.kind, e.value, e.expanded, e.exprErr = StringKind, e.expr, false, nil
+	}
+	switch e.kind {
+	case ArrayKind:
+		value, _ := e.value.([]*Entry)
+		for _, entry := range value {
+			entry.resetExpressions()
+		}
+	case ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		for _, entry := range value {
+			entry.resetExpressions()
+		}
+	}
+}
+
+// lookupKey returns the existing key of the object that matches the key, ignoring case when fold is set.
+// Returns the key itself when there is no match.
+func lookupKey(obj map[string]*Entry, key string, fold bool) string {
+	if _, exist := obj[key]; exist || !fold {
+		return key
+	}
+	match := ""
+	for k := range obj {
+		if strings.EqualFold(k, key) && (match == "" || k < match) {
+			match = k
+		}
+	}
+	if match == "" {
+		return key
+	}
+	return match
+}
+
+// foldKeys merges the keys that differ only in case, the most recent (see Origin.Order) takes precedence and
+// the casing of the first loaded is kept
+func (e *Entry) foldKeys() {
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		for _, entry := range list {
+			entry.foldKeys()
+		}
+	case ObjectKind:
+		obj, _ := e.value.(map[string]*Entry)
+		groups := map[string][]string{}
+		for key := range obj {
+			lower := strings.ToLower(key)
+			groups[lower] = append(groups[lower], key)
+		}
+		for _, keys := range groups {
+			if len(keys) < 2 {
+				continue
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				if oi, oj := obj[keys[i]].order(), obj[keys[j]].order(); oi != oj {
+					return oi < oj
+				}
+				return keys[i] < keys[j]
+			})
+			for _, key := range keys[1:] {
+				obj[keys[0]] = (&merger{fold: true}).mergeEntry(obj[keys[0]], obj[key], "")
+				delete(obj, key)
+			}
+		}
+		for _, entry := range obj {
+			entry.foldKeys()
+		}
+	}
+}
+
+// order load order of the effective value
+func (e *Entry) order() int {
+	if len(e.origins) == 0 {
+		return 0
+	}
+	return e.origins[0].Order
+}
@@ -4,9 +4,10 @@ import (
 	"strings"
 )
 
+// ParseEntry converts the value to an Entry, nil is a NullKind entry. Returns nil for unsupported types.
 func ParseEntry(v any) *Entry {
 	if v == nil {
-		return nil
+		return &Entry{kind: NullKind}
 	}
 	entry := &Entry{}
 	switch o := v.(type) {
@@ -151,7 +152,7 @@ func parseNumber(o float64, entry *Entry) {
 func parseEntryArray[T any](o []T, entry *Entry) {
 	var list []*Entry
 	for _, oc := range o {
-		if ce := ParseEntry(oc); ce != nil {
+		if ce := ParseEntry(oc); ce != nil && ce.kind != NullKind {
 			list = append(list, ce)
 		}
 	}
@@ -2,6 +2,7 @@ package cfg
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -15,17 +16,50 @@ import (
 type cacheEntry struct {
 	value any
 	exist bool
+	err   error // see ExpressionError
 }
 
 // Env global instance.
 type Env struct {
-	mutex      sync.RWMutex
-	fs         http.FileSystem
-	root       *Entry
-	cache      map[string]*cacheEntry
-	fileExts   map[string]UnmarshalFn
-	filePaths  []string
-	profileKey string
+	mutex       sync.RWMutex
+	fs          http.FileSystem
+	fsDefault   bool // fs is the working directory, not set by SetFileSystem
+	root        *Entry
+	cache       map[string]*cacheEntry
+	fileExts    map[string]UnmarshalFn
+	filePaths   []string
+	profileKey  string
+	dotEnvFiles []string
+	envMapping  EnvMapping
+
+	caseInsensitive     bool // see SetCaseInsensitive
+	strictInterpolation bool // see SetStrictInterpolation
+
+	expansions []expansion // expressions being expanded, see expand
+	expanded   bool        // expressions were expanded since the last reset, see resetExpressions
+
+	order     int                 // load order, see Origin
+	subs      map[int]*subscriber // OnChange subscribers
+	subsSeq   int
+	subsMutex sync.Mutex
+	values    map[string]any // expanded values of the leaves while there are subscribers, see mutate
+
+	rules          map[string][]RuleFn
+	validateOnLoad bool
+
+	base          *Entry        // state prior to Load, used by Reload
+	watchInterval time.Duration // polling interval used by Watch
+
+	recordVars bool       // records the variables loaded (loadVars), used by Load
+	varBatches []varBatch // recorded variables
+
+	sensitive      []string // patterns of sensitive keys, see MarkSensitive
+	sensitiveMutex sync.RWMutex
+
+	marshalFns map[string]MarshalFn  // serializers used by Marshal, by format
+	mergeRules []mergeRule           // array merge strategies, see SetMergeStrategy
+	resolvers  map[string]ResolverFn // resolvers of the references with scheme, see RegisterResolver
+	fileCustom bool                  // the "file" resolver is not the built-in resolveFile
 }
 
 // New default config
@@ -38,13 +72,31 @@ func New(defaults ...O) *Env {
 			"yml":  YamlUnmarshal,
 			"yaml": YamlUnmarshal,
 		},
-		filePaths:  []string{"config"},
-		profileKey: "profiles",
+		marshalFns: map[string]MarshalFn{
+			"json": JsonMarshal,
+			"yml":  YamlMarshal,
+			"yaml": YamlMarshal,
+			"toml": TomlMarshal,
+		},
+		resolvers: map[string]ResolverFn{
+			"env":    EnvResolver,
+			"base64": Base64Resolver,
+			"hex":    HexResolver,
+			"sys":    SysResolver,
+		},
+		fs:            defaultFileSystem(),
+		fsDefault:     true,
+		filePaths:     []string{"config"},
+		profileKey:    "profiles",
+		dotEnvFiles:   []string{".env", ".env.local", ".env.{profile}", ".env.{profile}.local"},
+		watchInterval: 2 * time.Second,
 	}
 
+	config.resolvers["file"] = config.resolveFile
+
 	if len(defaults) > 0 {
 		for _, cfg := range defaults {
-			config.LoadObject(cfg)
+			config.loadObject(cfg, Origin{Source: SourceDefault}, nil)
 		}
 	}
 
@@ -60,15 +112,52 @@ func (c *Env) Get(key string) any {
 }
 
 // Set a configuration property
+//
+// Array elements are addressed by index ("servers[0].host"), an index equal to the length of the array,
+// "[+]" or "[-1]" appends a new element ("servers[+].host"). A nil value removes the key, see Delete.
 func (c *Env) Set(key string, value any) {
-	if strings.IndexByte(key, '.') == -1 {
-		c.LoadObject(O{key: value})
-	} else {
-		c.set(key, value)
+	c.set(key, value)
+}
+
+// Delete removes the key (Ex. "db.password", "servers[1]"), the following elements of an array are shifted.
+// Same as Set(key, nil).
+func (c *Env) Delete(key string) {
+	path, err := parsePath(key)
+	if err != nil {
+		slog.Warn("[cfg] invalid config key.", slog.String("key", key), slog.Any("error", err))
+		return
 	}
+	c.mutate(SourceDelete, func() {
+		c.root.deletePath(path, c.caseInsensitive)
+	})
+}
+
+// SetCaseInsensitive enables the case-insensitive mode, keys that differ only in case ("DB.HOST" and "db.host")
+// are the same key in lookups, Set and merges. The casing of the first loaded key is kept (Keys, Marshal, Dump).
+// Existing keys that differ only in case are merged, the most recent value takes precedence.
+func (c *Env) SetCaseInsensitive(enabled bool) {
+	c.mutate(SourceMerge, func() {
+		c.caseInsensitive = enabled
+		if enabled {
+			c.root.foldKeys()
+		}
+	})
 }
 
-// Bool get a boolean value
+// SetStrictInterpolation makes the references to keys that do not exist (Ex. "${db.host}", without a default
+// value) an error, see ExpressionError. Returned by the Lookup methods, Bind and Load. The "-" operator only applies
+// when its key exists (Ex. "${read-timeout}" is an error when neither "read-timeout" nor "read" exist), ":-" always
+// applies.
+func (c *Env) SetStrictInterpolation(strict bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.strictInterpolation = strict
+	c.cache = map[string]*cacheEntry{}
+	c.resetExpressions()
+}
+
+// Bool get a boolean value. Strings are true when not empty (Ex. "false" is true), see LookupBool
 func (c *Env) Bool(key string) bool {
 	v := c.Get(key)
 	if v == nil {
@@ -86,11 +175,55 @@ func (c *Env) Bool(key string) bool {
 	}
 }
 
+// LookupBool get a boolean value. Strings must be parseable by strconv.ParseBool
+// or be "yes", "y", "on", "no", "n" or "off". Returns ErrKeyNotFound when the key
+// does not exist or *ConversionError when the value is invalid.
+func (c *Env) LookupBool(key string) (bool, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	b, err := toBool(v)
+	if err != nil {
+		return false, c.conversionError(key, v, "bool", err)
+	}
+	return b, nil
+}
+
+// toBool the conversion of the boolean values, shared by LookupBool and Bind
+func toBool(v any) (bool, error) {
+	switch s := v.(type) {
+	case bool:
+		return s, nil
+	case float64:
+		return s > 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "yes", "y", "on":
+			return true, nil
+		case "no", "n", "off":
+			return false, nil
+		}
+		return strconv.ParseBool(strings.TrimSpace(s))
+	default:
+		return false, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
 // String get a string value
 func (c *Env) String(key string, def ...string) string {
 	v := c.Get(key)
 
-	return c.toString(v, def...)
+	return c.toString(key, v, def...)
+}
+
+// LookupString get a string value. Returns ErrKeyNotFound when the key does not exist.
+func (c *Env) LookupString(key string) (string, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return c.toString(key, v), nil
 }
 
 // Strings get a string array values
@@ -105,16 +238,16 @@ func (c *Env) Strings(key string, def ...[]string) []string {
 	switch s := v.(type) {
 	case []any:
 		var list []string
-		for _, it := range s {
-			list = append(list, c.toString(it))
+		for i, it := range s {
+			list = append(list, c.toString(key+"["+strconv.Itoa(i)+"]", it))
 		}
 		return list
 	default:
-		return []string{c.toString(s)}
+		return []string{c.toString(key, s)}
 	}
 }
 
-func (c *Env) toString(v any, def ...string) string {
+func (c *Env) toString(key string, v any, def ...string) string {
 	var out string
 	if v != nil {
 		switch s := v.(type) {
@@ -127,7 +260,8 @@ func (c *Env) toString(v any, def ...string) string {
 				slog.Warn(
 					"[cfg] cannot convert value to string using json.Marshal",
 					slog.Any("error", err),
-					slog.Any("value", s),
+					slog.String("key", key),
+					slog.Any("value", c.logValue(key, s)),
 				)
 				out = strings.TrimPrefix(fmt.Sprintf("%#v", s), "map[string]interface {}")
 			} else {
@@ -138,7 +272,8 @@ func (c *Env) toString(v any, def ...string) string {
 				slog.Warn(
 					"[cfg] cannot convert value to string using json.Marshal",
 					slog.Any("error", err),
-					slog.Any("value", s),
+					slog.String("key", key),
+					slog.Any("value", c.logValue(key, s)),
 				)
 			} else {
 				out = string(b)
@@ -163,21 +298,33 @@ func (c *Env) toString(v any, def ...string) string {
 
 // Duration get a duration from config
 func (c *Env) Duration(key string, def ...time.Duration) time.Duration {
-	value := c.String(key)
-	if out, err := time.ParseDuration(value); err == nil {
+	if out, err := c.LookupDuration(key); err == nil {
 		return out
-	} else {
+	} else if !errors.Is(err, ErrKeyNotFound) {
 		slog.Error(
 			"[cfg] could not be converted to time.Duration.",
 			slog.Any("error", err),
 			slog.String("key", key),
-			slog.String("value", value),
 		)
-		if len(def) > 0 {
-			return def[0]
-		}
-		return 0
 	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// LookupDuration get a duration from config. Returns ErrKeyNotFound when
+// the key does not exist or *ConversionError when the value is invalid.
+func (c *Env) LookupDuration(key string) (time.Duration, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	out, err := time.ParseDuration(c.toString(key, v))
+	if err != nil {
+		return 0, c.conversionError(key, v, "time.Duration", err)
+	}
+	return out, nil
 }
 
 // Time get a time from config
@@ -199,39 +346,73 @@ func (c *Env) TimeOnly(key string, def ...time.Time) time.Time {
 
 // TimeLayout get a time.Time using a layout
 func (c *Env) TimeLayout(key string, layout string, def ...time.Time) time.Time {
-	value := c.String(key)
-	if out, err := time.Parse(layout, value); err == nil {
+	if out, err := c.LookupTimeLayout(key, layout); err == nil {
 		return out
-	} else {
+	} else if !errors.Is(err, ErrKeyNotFound) {
 		slog.Error(
 			"[cfg] could not be converted to time.Time.",
 			slog.Any("error", err),
 			slog.String("key", key),
-			slog.String("value", value),
 			slog.String("layout", layout),
 		)
-		if len(def) > 0 {
-			return def[0]
-		}
-		return time.Time{}
 	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return time.Time{}
+}
+
+// LookupTime get a time from config (time.RFC3339), see LookupTimeLayout.
+func (c *Env) LookupTime(key string) (time.Time, error) {
+	return c.LookupTimeLayout(key, time.RFC3339)
+}
+
+// LookupTimeLayout get a time.Time using a layout. Returns ErrKeyNotFound when
+// the key does not exist or *ConversionError when the value is invalid.
+func (c *Env) LookupTimeLayout(key string, layout string) (time.Time, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	out, err := time.Parse(layout, c.toString(key, v))
+	if err != nil {
+		return time.Time{}, c.conversionError(key, v, "time.Time", err)
+	}
+	return out, nil
 }
 
 // Clone make a copy of the config
 func (c *Env) Clone() *Env {
+	unlock := c.lock(true)
+	defer unlock()
+
 	o := New()
 	o.root = c.root.Clone()
+	o.expanded = c.expanded
+	o.order = c.order
+	o.caseInsensitive = c.caseInsensitive
+	o.strictInterpolation = c.strictInterpolation
+	o.mergeRules = append([]mergeRule{}, c.mergeRules...)
+	o.fs, o.fsDefault = c.fs, c.fsDefault
+	c.copyResolvers(o)
+	c.sensitiveMutex.RLock()
+	o.sensitive = append([]string{}, c.sensitive...)
+	c.sensitiveMutex.RUnlock()
 	return o
 }
 
 func (c *Env) Keys(key string) []string {
-	unlock := c.lock(true)
+	unlock := c.lock(false)
 	defer unlock()
 
 	entry := c.getEntryUnsafe(key)
 	if entry == nil {
 		return nil
 	}
+	if entry.expr != "" {
+		// whole reference to an array or object (Ex. "${server}")
+		_ = c.expand(entry, key)
+	}
 	switch entry.kind {
 	case BoolKind, StringKind, NumberKind:
 		return nil
@@ -254,16 +435,16 @@ func (c *Env) Keys(key string) []string {
 
 // Merge merge src into the current config
 func (c *Env) Merge(src *Env) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	c.root.Merge(src.root)
-	c.cache = map[string]*cacheEntry{}
+	src.mutex.RLock()
+	root := src.root.Clone()
+	order := src.order
+	src.mutex.RUnlock()
+	root.resetExpressions()
 
-	// clear expressions
-	c.root.Walk(func(entry *Entry) {
-		if entry.expr != "" {
-			entry.value = entry.expr
-		}
+	c.mutate(SourceMerge, func() {
+		// src values are loaded after the current ones
+		root.shiftOrder(c.order)
+		c.order += order
+		c.merger().merge(c.root, root, "")
 	})
 }
@@ -0,0 +1,316 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Bind populates dst (a non-nil pointer) with the configuration found under prefix.
+//
+// Struct fields are mapped using the `cfg:"name"` tag (`cfg:"-"` ignores the field),
+// fields without the tag are matched by name (case-insensitive). When a key does not
+// exist, the value of the `default:"..."` tag is used instead (comma-separated for
+// slices). time.Time fields accept a `layout:"..."` tag (default time.RFC3339) and
+// the `validate:"..."` tag declares rules (see parseRules), violations are returned
+// as ValidationErrors.
+//
+// Values are converted using the same rules as the Lookup methods (LookupInt,
+// LookupBool, ...) or a converter defined with RegisterConverter, invalid values
+// are reported as *ConversionError.
+func (c *Env) Bind(prefix string, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cfg: Bind requires a non-nil pointer, got %T", dst)
+	}
+
+	value, exist, err := c.getValue(prefix)
+	if err != nil {
+		return err
+	} else if !exist {
+		value = map[string]any{}
+	}
+	return c.bind(prefix, value, rv.Elem(), reflect.StructTag(""))
+}
+
+// Unmarshal populates dst with the whole configuration, see Bind.
+func (c *Env) Unmarshal(dst any) error {
+	return c.Bind("", dst)
+}
+
+// getValue same as fetch, also accepts the empty key (root).
+func (c *Env) getValue(key string) (any, bool, error) {
+	if key != "" {
+		return c.fetch(key)
+	}
+
+	unlock := c.lock(false)
+	defer unlock()
+
+	err := c.expand(c.root, "")
+	return c.root.Value(), true, err
+}
+
+func (c *Env) bind(key string, value any, rv reflect.Value, tag reflect.StructTag) error {
+	if fn, exist := converterOf(rv.Type()); exist {
+		out, err := fn(value)
+		if err != nil {
+			return c.bindError(key, value, rv, err)
+		}
+		if out == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+		} else {
+			rv.Set(reflect.ValueOf(out))
+		}
+		return nil
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return c.bind(key, value, rv.Elem(), tag)
+	}
+
+	switch rv.Type() {
+	case durationType:
+		s := c.toString(key, value)
+		if d, err := time.ParseDuration(s); err != nil {
+			return c.bindError(key, value, rv, err)
+		} else {
+			rv.SetInt(int64(d))
+		}
+		return nil
+	case timeType:
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		if t, err := time.Parse(layout, c.toString(key, value)); err != nil {
+			return c.bindError(key, value, rv, err)
+		} else {
+			rv.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return c.bindError(key, value, rv, fmt.Errorf("value is not an object"))
+		}
+		return c.bindStruct(key, obj, rv)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return c.bindError(key, value, rv, fmt.Errorf("map key must be a string"))
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return c.bindError(key, value, rv, fmt.Errorf("value is not an object"))
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(obj)))
+		}
+		var errs ValidationErrors
+		for _, k := range sortedKeys(obj) {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := c.bind(joinKey(key, Escape(k)), obj[k], elem, ""); err != nil {
+				if verrs, ok := err.(ValidationErrors); ok {
+					errs = append(errs, verrs...)
+				} else {
+					return err
+				}
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), elem)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	case reflect.Slice:
+		list, ok := value.([]any)
+		if !ok {
+			list = []any{value}
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(list), len(list))
+		var errs ValidationErrors
+		for i, v := range list {
+			if err := c.bind(fmt.Sprintf("%s[%d]", key, i), v, slice.Index(i), tag); err != nil {
+				if verrs, ok := err.(ValidationErrors); ok {
+					errs = append(errs, verrs...)
+				} else {
+					return err
+				}
+			}
+		}
+		rv.Set(slice)
+		if len(errs) > 0 {
+			return errs
+		}
+	case reflect.Interface:
+		if rv.NumMethod() > 0 {
+			return c.bindError(key, value, rv, fmt.Errorf("unsupported type"))
+		}
+		if value != nil {
+			rv.Set(reflect.ValueOf(value))
+		}
+	case reflect.String:
+		rv.SetString(c.toString(key, value))
+	case reflect.Bool:
+		b, err := toBool(value)
+		if err != nil {
+			return c.bindError(key, value, rv, err)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt(value)
+		if err != nil {
+			return c.bindError(key, value, rv, err)
+		} else if rv.OverflowInt(int64(i)) {
+			return c.bindError(key, value, rv, fmt.Errorf("value %d overflows", i))
+		}
+		rv.SetInt(int64(i))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := toInt(value)
+		if err != nil {
+			return c.bindError(key, value, rv, err)
+		} else if i < 0 || rv.OverflowUint(uint64(i)) {
+			return c.bindError(key, value, rv, fmt.Errorf("value %d overflows", i))
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil {
+			return c.bindError(key, value, rv, err)
+		} else if rv.OverflowFloat(f) {
+			return c.bindError(key, value, rv, fmt.Errorf("value %v overflows", f))
+		}
+		rv.SetFloat(f)
+	default:
+		return c.bindError(key, value, rv, fmt.Errorf("unsupported type"))
+	}
+	return nil
+}
+
+func (c *Env) bindStruct(key string, obj map[string]any, rv reflect.Value) error {
+	var errs ValidationErrors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, hasTag := field.Tag.Lookup("cfg")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if field.Anonymous && !hasTag {
+			// embedded struct, fields are promoted
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Pointer {
+					if !fv.CanSet() {
+						continue
+					}
+					if fv.IsNil() {
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := c.bindStruct(key, obj, fv); err != nil {
+					if verrs, ok := err.(ValidationErrors); ok {
+						errs = append(errs, verrs...)
+					} else {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		if !fv.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		value, exist := obj[name]
+		if !exist {
+			for k, v := range obj {
+				if strings.EqualFold(k, name) {
+					value, exist = v, true
+					break
+				}
+			}
+		}
+
+		fkey := joinKey(key, name)
+		if !exist {
+			if def, hasDef := field.Tag.Lookup("default"); hasDef {
+				value, exist = defaultValue(def, field.Type), true
+			}
+		}
+
+		if verrs, err := validateField(fkey, field, value, exist); err != nil {
+			return err
+		} else {
+			errs = append(errs, verrs...)
+		}
+
+		if !exist {
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				// nested defaults
+				value = map[string]any{}
+			} else {
+				continue
+			}
+		}
+
+		if err := c.bind(fkey, value, fv, field.Tag); err != nil {
+			if verrs, ok := err.(ValidationErrors); ok {
+				errs = append(errs, verrs...)
+			} else {
+				return err
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// defaultValue converts the content of the `default` tag
+func defaultValue(def string, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice {
+		return def
+	}
+	var list []any
+	for _, s := range strings.Split(def, ",") {
+		list = append(list, strings.TrimSpace(s))
+	}
+	return list
+}
+
+func (c *Env) bindError(key string, value any, rv reflect.Value, err error) error {
+	return c.conversionError(key, value, rv.Type().String(), err)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,145 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBindDB struct {
+	Host    string        `cfg:"host"`
+	Port    int           `cfg:"port" default:"5432"`
+	Timeout time.Duration `cfg:"timeout" default:"5s"`
+}
+
+type testBindBase struct {
+	Name string `cfg:"name"`
+}
+
+type testBindApp struct {
+	testBindBase
+	Description string            `cfg:"description"`
+	Debug       bool              `cfg:"debug"`
+	Ratio       float32           `cfg:"ratio"`
+	Workers     uint8             `cfg:"workers"`
+	DB          testBindDB        `cfg:"db"`
+	Replica     *testBindDB       `cfg:"replica"`
+	Missing     *testBindDB       `cfg:"missing"`
+	Tags        []string          `cfg:"tags"`
+	Hosts       []string          `cfg:"hosts" default:"a, b"`
+	Servers     []testBindDB      `cfg:"servers"`
+	Labels      map[string]string `cfg:"labels"`
+	Started     time.Time         `cfg:"started"`
+	Day         time.Time         `cfg:"day" layout:"2006-01-02"`
+	Extra       any               `cfg:"extra"`
+	Ignored     string            `cfg:"-"`
+	Version     string
+}
+
+func TestEnv_Bind(t *testing.T) {
+	env := New(O{
+		"app": O{
+			"name":        "My App",
+			"description": "${app.name} description",
+			"debug":       true,
+			"ratio":       0.5,
+			"workers":     4,
+			"db":          O{"host": "localhost", "timeout": "1m"},
+			"replica":     O{"host": "replica", "port": "5433"},
+			"tags":        []string{"a", "b"},
+			"servers":     []O{{"host": "s1", "port": 1}, {"host": "s2"}},
+			"labels":      O{"env": "dev"},
+			"started":     "2023-10-10T10:00:00Z",
+			"day":         "2023-10-10",
+			"extra":       O{"key": "value"},
+			"ignored":     "ignored",
+			"VERSION":     "1.0",
+		},
+	})
+
+	var got testBindApp
+	if err := env.Bind("app", &got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	want := testBindApp{
+		testBindBase: testBindBase{Name: "My App"},
+		Description:  "My App description",
+		Debug:        true,
+		Ratio:        0.5,
+		Workers:      4,
+		DB:           testBindDB{Host: "localhost", Port: 5432, Timeout: time.Minute},
+		Replica:      &testBindDB{Host: "replica", Port: 5433, Timeout: 5 * time.Second},
+		Tags:         []string{"a", "b"},
+		Hosts:        []string{"a", "b"},
+		Servers: []testBindDB{
+			{Host: "s1", Port: 1, Timeout: 5 * time.Second},
+			{Host: "s2", Port: 5432, Timeout: 5 * time.Second},
+		},
+		Labels:  map[string]string{"env": "dev"},
+		Started: time.Date(2023, 10, 10, 10, 0, 0, 0, time.UTC),
+		Day:     time.Date(2023, 10, 10, 0, 0, 0, 0, time.UTC),
+		Extra:   map[string]any{"key": "value"},
+		Version: "1.0",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bind() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEnv_Bind_Errors(t *testing.T) {
+	env := New(O{
+		"db":    O{"host": "localhost", "timeout": "invalid"},
+		"small": O{"port": 1000},
+	})
+
+	var db testBindDB
+	if err := env.Bind("db", db); err == nil {
+		t.Errorf("Bind() expected error for non pointer")
+	}
+	if err := env.Bind("db", &db); err == nil {
+		t.Errorf("Bind() expected error for invalid duration")
+	}
+
+	var small struct {
+		Port int8 `cfg:"port"`
+	}
+	if err := env.Bind("small", &small); err == nil {
+		t.Errorf("Bind() expected overflow error")
+	}
+}
+
+func TestEnv_Unmarshal(t *testing.T) {
+	env := New(O{"db": O{"host": "localhost"}, "empty": O{}})
+
+	var got struct {
+		DB    testBindDB     `cfg:"db"`
+		Empty map[string]int `cfg:"empty"`
+	}
+	if err := env.Unmarshal(&got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := testBindDB{Host: "localhost", Port: 5432, Timeout: 5 * time.Second}
+	if !reflect.DeepEqual(got.DB, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got.DB, want)
+	}
+}
+
+func TestEnv_BindBool(t *testing.T) {
+	env := New(O{"flags": O{"a": "yes", "b": "Off", "c": "1", "d": "false", "e": true}})
+
+	var got map[string]bool
+	if err := env.Bind("flags", &got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	for key, value := range got {
+		// the bound values are the values of LookupBool
+		if b, err := env.LookupBool("flags." + key); err != nil || b != value {
+			t.Errorf("Bind() %s = %v, LookupBool() = %v, %v", key, value, b, err)
+		}
+	}
+	if want := map[string]bool{"a": true, "b": false, "c": true, "d": false, "e": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Bind() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package cfg
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Sources of config changes and values
+const (
+	SourceDefault = "default" // cfg.New(defaults)
+	SourceObject  = "object"  // LoadObject
+	SourceSet     = "set"     // Set
+	SourceDelete  = "delete"  // Delete
+	SourceFile    = "file"    // config files (LoadFiles, LoadProfiles)
+	SourceDotEnv  = "dotenv"  // LoadDotEnv
+	SourceEnv     = "env"     // LoadOsEnv
+	SourceArgs    = "args"    // LoadOsArgs
+	SourceEnviron = "environ" // LoadEnviron
+	SourceMerge   = "merge"   // Merge
+	SourceReload  = "reload"  // Reload
+)
+
+// ChangeEvent describes a leaf value changed in the config. Values are expanded, a key referencing a changed
+// key (Ex. "${db.host}") also changes. nil when the key was added or removed.
+type ChangeEvent struct {
+	Key    string // dotted key (Ex. "server.port", "servers[0].host")
+	Old    any    // previous value
+	New    any    // current value
+	Source string // origin of the change (SourceObject, SourceFile, SourceReload, ...)
+}
+
+type subscriber struct {
+	prefix string
+	fn     func(ev ChangeEvent)
+}
+
+// OnChange registers a callback invoked for every change in a key equal to or under prefix
+// (empty prefix receives all changes). Callbacks are invoked after the change is applied,
+// without holding the Env lock. Returns a function that removes the subscription.
+func (c *Env) OnChange(prefix string, fn func(ev ChangeEvent)) (unsubscribe func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.subsMutex.Lock()
+	defer c.subsMutex.Unlock()
+
+	if c.subs == nil {
+		c.subs = map[int]*subscriber{}
+	}
+	c.subsSeq++
+	id := c.subsSeq
+	c.subs[id] = &subscriber{prefix: prefix, fn: fn}
+
+	// the keys of the prefix are tracked from now on
+	c.values = c.flattenValues()
+
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.subsMutex.Lock()
+		defer c.subsMutex.Unlock()
+
+		delete(c.subs, id)
+		if len(c.subs) == 0 {
+			// the changes are no longer tracked
+			c.values = nil
+		} else {
+			c.values = c.flattenValues()
+		}
+	}
+}
+
+// mutate applies changes to the config tree, clears the cache and notifies the subscribers.
+func (c *Env) mutate(source string, fn func()) {
+	c.mutex.Lock()
+
+	// the changes are applied to the expressions, not to their expanded values (Ex. a whole reference to an
+	// object), they are expanded again on access
+	c.resetExpressions()
+
+	fn()
+
+	c.cache = map[string]*cacheEntry{}
+
+	var events []ChangeEvent
+	if c.values != nil {
+		// there are subscribers, the watched keys are compared with the values of the previous change
+		c.subsMutex.Lock()
+		values := c.flattenValues()
+		c.subsMutex.Unlock()
+		events = diffValues(c.values, values, source)
+		c.values = values
+	}
+
+	c.mutex.Unlock()
+
+	c.notify(events)
+}
+
+func (c *Env) notify(events []ChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	c.subsMutex.Lock()
+	ids := make([]int, 0, len(c.subs))
+	for id := range c.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subs := make([]*subscriber, 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, c.subs[id])
+	}
+	c.subsMutex.Unlock()
+
+	for _, ev := range events {
+		for _, sub := range subs {
+			if hasKeyPrefix(ev.Key, sub.prefix) {
+				sub.fn(ev)
+			}
+		}
+	}
+}
+
+// hasKeyPrefix checks if key is equal to or is a child of prefix
+func hasKeyPrefix(key, prefix string) bool {
+	if prefix == "" || key == prefix {
+		return true
+	}
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	next := key[len(prefix)]
+	return next == '.' || next == '['
+}
+
+// flattenValues expanded values of the leaves watched by the subscribers, internal use (write lock and
+// subsMutex). Only these keys are expanded, the errors of the expressions are returned on access.
+func (c *Env) flattenValues() map[string]any {
+	values := map[string]any{}
+	for _, prefix := range watchedPrefixes(c.subs) {
+		entry := c.root
+		if prefix != "" {
+			if entry = c.getEntryUnsafe(prefix); entry == nil {
+				continue
+			}
+		}
+		_ = c.expand(entry, prefix)
+		entry.walkKeys(prefix, func(key string, e *Entry) {
+			values[key] = e.Value()
+		})
+	}
+	return values
+}
+
+// watchedPrefixes the prefixes of the subscribers, without the ones under another prefix
+func watchedPrefixes(subs map[int]*subscriber) []string {
+	var prefixes []string
+	for _, sub := range subs {
+		prefixes = append(prefixes, sub.prefix)
+	}
+	sort.Strings(prefixes)
+
+	var out []string
+	for _, prefix := range prefixes {
+		covered := false
+		for _, p := range out {
+			if hasKeyPrefix(prefix, p) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, prefix)
+		}
+	}
+	return out
+}
+
+func diffValues(before, after map[string]any, source string) []ChangeEvent {
+	var events []ChangeEvent
+	for key, old := range before {
+		if value, exist := after[key]; !exist {
+			events = append(events, ChangeEvent{Key: key, Old: old, Source: source})
+		} else if !reflect.DeepEqual(old, value) {
+			events = append(events, ChangeEvent{Key: key, Old: old, New: value, Source: source})
+		}
+	}
+	for key, value := range after {
+		if _, exist := before[key]; !exist {
+			events = append(events, ChangeEvent{Key: key, New: value, Source: source})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+	return events
+}
@@ -0,0 +1,134 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnv_OnChange(t *testing.T) {
+	env := New(O{
+		"server": O{"port": 80, "host": "localhost"},
+		"db":     O{"host": "db"},
+	})
+
+	var server, all []ChangeEvent
+	unsubscribe := env.OnChange("server", func(ev ChangeEvent) {
+		// callbacks are invoked without holding the lock
+		env.Get(ev.Key)
+		server = append(server, ev)
+	})
+	env.OnChange("", func(ev ChangeEvent) {
+		all = append(all, ev)
+	})
+
+	env.LoadObject(O{"server": O{"port": 8080, "host": "localhost", "tls": true}})
+	env.Set("db.host", "db2")
+	env.Set("db.host", "db2") // no changes
+
+	want := []ChangeEvent{
+		{Key: "server.port", Old: float64(80), New: float64(8080), Source: SourceObject},
+		{Key: "server.tls", New: true, Source: SourceObject},
+	}
+	if !reflect.DeepEqual(server, want) {
+		t.Errorf("OnChange() = %+v, want %+v", server, want)
+	}
+
+	want = append(want, ChangeEvent{Key: "db.host", Old: "db", New: "db2", Source: SourceSet})
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("OnChange() = %+v, want %+v", all, want)
+	}
+
+	unsubscribe()
+	env.Merge(New(O{"server": O{"port": 9090}}))
+
+	if len(server) != 2 {
+		t.Errorf("OnChange() called after unsubscribe")
+	}
+	last := all[len(all)-1]
+	if last.Key != "server.port" || last.Source != SourceMerge {
+		t.Errorf("OnChange() = %+v", last)
+	}
+}
+
+func TestEnv_OnChange_Expressions(t *testing.T) {
+	env := New(O{
+		"db":     O{"host": "localhost", "port": 5432},
+		"url":    "postgres://${db.host}:${db.port}",
+		"backup": "${db}",
+	})
+
+	var events []ChangeEvent
+	env.OnChange("", func(ev ChangeEvent) {
+		events = append(events, ev)
+	})
+
+	env.Set("db.host", "db")
+
+	want := []ChangeEvent{
+		{Key: "backup.host", Old: "localhost", New: "db", Source: SourceSet},
+		{Key: "db.host", Old: "localhost", New: "db", Source: SourceSet},
+		{Key: "url", Old: "postgres://localhost:5432", New: "postgres://db:5432", Source: SourceSet},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("OnChange() = %+v, want %+v", events, want)
+	}
+}
+
+func TestEnv_OnChange_Watched(t *testing.T) {
+	env := New(O{
+		"db":     O{"host": "localhost"},
+		"url":    "postgres://${db.host}",
+		"secret": "${count:db}",
+	})
+	calls := 0
+	env.RegisterResolver("count", func(arg string) (string, error) {
+		calls++
+		return arg, nil
+	})
+
+	var events []ChangeEvent
+	env.OnChange("url", func(ev ChangeEvent) {
+		events = append(events, ev)
+	})
+
+	env.Set("db.host", "db")
+	env.Set("db.port", 5432)
+
+	// only the keys watched by the subscribers are expanded
+	if calls != 0 {
+		t.Errorf("resolver calls = %d, want 0", calls)
+	}
+	want := []ChangeEvent{{Key: "url", Old: "postgres://localhost", New: "postgres://db", Source: SourceSet}}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("OnChange() = %+v, want %+v", events, want)
+	}
+
+	env.OnChange("secret", func(ev ChangeEvent) {})
+	env.Set("db.host", "other")
+	if calls != 2 {
+		t.Errorf("resolver calls = %d, want 2", calls)
+	}
+}
+
+func TestEnv_LoadObject_Expressions(t *testing.T) {
+	env := New(O{"a": "${b}", "b": "x"})
+
+	if got := env.Get("a"); got != "x" {
+		t.Errorf("Get() = %v, want %v", got, "x")
+	}
+
+	env.LoadObject(O{"a": "plain"})
+	if got := env.Get("a"); got != "plain" {
+		t.Errorf("Get() = %v, want %v", got, "plain")
+	}
+
+	env.LoadObject(O{"a": "${c}", "c": "y"})
+	if got := env.Get("a"); got != "y" {
+		t.Errorf("Get() = %v, want %v", got, "y")
+	}
+
+	env.LoadObject(O{})
+	if got := env.Get("a"); got != "y" {
+		t.Errorf("Get() = %v, want %v", got, "y")
+	}
+}
@@ -1,13 +1,15 @@
 package cfg
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 )
 
 // Float get a floating value from a config
 func (c *Env) Float(key string, def ...float64) float64 {
-	if v := c.Get(key); v != nil {
-		return floatValue(v)
+	if v, err := c.LookupFloat(key); err == nil {
+		return v
 	}
 	if len(def) > 0 {
 		return def[0]
@@ -15,22 +17,32 @@ func (c *Env) Float(key string, def ...float64) float64 {
 	return 0
 }
 
-func floatValue(val any) float64 {
-	var value float64
+// LookupFloat get a floating value from a config. Returns ErrKeyNotFound
+// when the key does not exist or *ConversionError when the value is invalid.
+func (c *Env) LookupFloat(key string) (float64, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, c.conversionError(key, v, "float64", err)
+	}
+	return f, nil
+}
+
+func toFloat(val any) (float64, error) {
 	switch t := val.(type) {
 	case float64:
-		value = t
+		return t, nil
 	case bool:
 		if t {
-			value = 1
-		} else {
-			value = 0
+			return 1, nil
 		}
+		return 0, nil
 	case string:
-		if i, errInt := strconv.ParseFloat(t, 64); errInt == nil {
-			value = i
-		}
+		return strconv.ParseFloat(strings.TrimSpace(t), 64)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", val)
 	}
-
-	return value
 }
@@ -1,13 +1,15 @@
 package cfg
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 )
 
 // Int get an integer value from a config
 func (c *Env) Int(key string, def ...int) int {
-	if v := c.Get(key); v != nil {
-		return intValue(v)
+	if v, err := c.LookupInt(key); err == nil {
+		return v
 	}
 	if len(def) > 0 {
 		return def[0]
@@ -15,22 +17,36 @@ func (c *Env) Int(key string, def ...int) int {
 	return 0
 }
 
-func intValue(val any) int {
-	var value int
+// LookupInt get an integer value from a config. Returns ErrKeyNotFound
+// when the key does not exist or *ConversionError when the value is invalid.
+func (c *Env) LookupInt(key string) (int, error) {
+	v, err := c.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := toInt(v)
+	if err != nil {
+		return 0, c.conversionError(key, v, "int", err)
+	}
+	return i, nil
+}
+
+func toInt(val any) (int, error) {
 	switch t := val.(type) {
 	case float64:
-		value = int(t)
+		return int(t), nil
 	case bool:
 		if t {
-			value = 1
-		} else {
-			value = 0
+			return 1, nil
 		}
+		return 0, nil
 	case string:
-		if i, errInt := strconv.ParseInt(t, 10, 64); errInt == nil {
-			value = int(i)
+		i, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		if err != nil {
+			return 0, err
 		}
+		return int(i), nil
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", val)
 	}
-
-	return value
 }
@@ -1,19 +1,25 @@
 package cfg
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"strconv"
 	"strings"
 )
 
 func (c *Env) get(key string) (any, bool) {
+	value, exist, _ := c.fetch(key)
+	return value, exist
+}
+
+// fetch same as get, also returns the error of the expressions of the value (see ExpressionError)
+func (c *Env) fetch(key string) (any, bool, error) {
 	unlock := c.lock(true)
 
 	if e, exist := c.cache[key]; exist { // 1st check
 		unlock()
-		return e.value, e.exist
+		return e.value, e.exist, e.err
 	} else {
 		unlock()
 	}
@@ -22,128 +28,85 @@ func (c *Env) get(key string) (any, bool) {
 	defer unlock()
 
 	if e, exist := c.cache[key]; exist { // 2nd (double) check
-		return e.value, e.exist
+		return e.value, e.exist, e.err
 	}
 
-	return c.getValueUnsafe(key)
+	value, exist, err := c.getValueUnsafe(key)
+	if err != nil {
+		// only the key requested, not the keys it references
+		slog.Error("[cfg] cannot expand the value.", slog.Any("error", err), slog.String("key", key))
+	}
+	return value, exist, err
 }
 
-func (c *Env) set(key string, value any) {
-
-	indexOpenBracket := strings.IndexByte(key, '[')
+// lookup same as get, returns ErrKeyNotFound when the key does not exist or *ExpressionError when the
+// value cannot be expanded
+func (c *Env) lookup(key string) (any, error) {
+	if v, exist, err := c.fetch(key); err != nil {
+		return nil, err
+	} else if !exist || v == nil {
+		return nil, keyNotFound(key)
+	} else {
+		return v, nil
+	}
+}
 
-	if indexOpenBracket == 0 {
-		slog.Warn("[cfg] invalid config key.", slog.String("key", key))
+// set merges the value at the key, array elements are addressed by index ("servers[0].host"), an index equal
+// to the length of the array or "[+]" appends a new element
+func (c *Env) set(key string, value any) {
+	path, err := parsePath(key)
+	if err != nil {
+		slog.Warn("[cfg] invalid config key.", slog.String("key", key), slog.Any("error", err))
 		return
 	}
-
-	object := map[string]any{}
-	var segment string
-	var prev map[string]any
-	var curr = object
-
-	if indexOpenBracket == -1 {
-		// simple key (fast)
-
-		if strings.IndexByte(key, ']') >= 0 {
-			// "prop.array0]"
-			slog.Warn("[cfg] invalid brackets in config key.", slog.String("key", key))
-			return
-		}
-
-		for _, segment = range Segments(key) {
-			segment = strings.TrimSpace(segment)
-			if segment == "" {
-				// "prop..value" || "prop. .value"
-				slog.Warn("[cfg] invalid config key.", slog.String("key", key))
-				return
-			}
-
-			prev = curr
-			curr[segment] = map[string]any{}
-			curr = curr[segment].(map[string]any)
-		}
-
-	} else {
-		// complex key (array)
-		if strings.IndexByte(key, ']') == -1 {
-			// "prop.array[0"
-			slog.Warn("[cfg] invalid brackets in config key.", slog.String("key", key))
-			return
-		}
-
-		for _, segment = range Segments(key) {
-			segment = strings.TrimSpace(segment)
-			if segment == "" {
-				// "prop..value" || "prop. .value"
-				slog.Warn("[cfg] invalid config key.", slog.String("key", key), slog.String("segment", segment))
-				return
-			}
-
-			// "prop.array[0]" => pkey = "array[0]"
-			indexOpen := strings.IndexByte(key, '[')
-
-			if indexOpen == 0 || (indexOpen >= 0 && indexOpen != len(segment)-1) {
-				// "prop.[0]" || "prop.array[0]text"
-				slog.Warn("[cfg] invalid brackets in config key.", slog.String("key", key), slog.String("segment", segment))
-				return
-			}
-
-			indexClose := strings.IndexByte(key, ']')
-
-			if indexOpen != indexClose && indexClose-indexOpen < 0 {
-				// "prop.array[0" || "prop.array0]"  || "prop.array0]tex[t" || "prop.array[]"
-				slog.Warn("[cfg] invalid brackets in config key.", slog.String("key", key), slog.String("segment", segment))
-				return
-			}
-
-			if indexOpen >= 0 {
-
-			} else {
-				prev = curr
-				curr[segment] = map[string]any{}
-				curr = curr[segment].(map[string]any)
-			}
-
-		}
+	entry := ParseEntry(value)
+	if entry == nil {
+		return
 	}
 
-	prev[segment] = value
-
-	c.LoadObject(object)
+	c.mutate(SourceSet, func() {
+		c.order++
+		origin := Origin{Source: SourceSet, Order: c.order}
+		entry.stamp(key, origin, nil)
+		if err = c.root.setPath(path, entry, origin, c.merger()); err != nil {
+			slog.Warn("[cfg] invalid config key.", slog.String("key", key), slog.Any("error", err))
+		}
+	})
 }
 
-func (c *Env) getStringUnsafe(key string) string {
-	if v, exist := c.getValueUnsafe(key); !exist || v == nil {
-		return ""
+// getStringUnsafe the value of the key as string, used by the expressions
+func (c *Env) getStringUnsafe(key string) (string, bool, error) {
+	if v, exist, err := c.getValueUnsafe(key); err != nil || !exist || v == nil {
+		return "", false, err
 	} else {
 		switch s := v.(type) {
 		case string:
-			return s
+			return s, true, nil
 		case []string:
-			return strings.Join(s, ",")
+			return strings.Join(s, ","), true, nil
 		default:
-			return fmt.Sprintf("%v", s)
+			return fmt.Sprintf("%v", s), true, nil
 		}
 	}
 }
 
 // getValueUnsafe internal use, non-blocking call. Only use
 // when asynchronous access control is active, see get method.
-func (c *Env) getValueUnsafe(key string) (any, bool) {
+func (c *Env) getValueUnsafe(key string) (any, bool, error) {
 
 	if e, exist := c.cache[key]; exist {
-		return e.value, e.exist
+		return e.value, e.exist, e.err
 	}
 
 	exist := false
 	var value any
+	var err error
 
 	defer func() {
 		// Deferred function calls are pushed onto a stack.
 		// When a function returns, its deferred calls are executed in last-in-first-out order.
 		// https://go.dev/tour/flowcontrol/13
-		c.cache[key] = &cacheEntry{value: value, exist: exist}
+		c.cache[key] = &cacheEntry{value: value, exist: exist, err: err}
 	}()
 
 	entry := c.getEntryUnsafe(key)
@@ -151,52 +114,43 @@ func (c *Env) getValueUnsafe(key string) (any, bool) {
 	if entry != nil {
 		exist = true
 		// lazy string evaluation
-		c.expand(entry)
+		err = c.expand(entry, key)
 		value = entry.Value()
 	}
-	return value, exist
+	return value, exist, err
 }
 
 // getEntryUnsafe internal use, non-blocking call. Only use
-// when asynchronous access control is active, see get method.
+// when asynchronous access control is active (write lock), see get method.
 func (c *Env) getEntryUnsafe(key string) *Entry {
+	path, err := parsePath(key)
+	if err != nil {
+		return nil
+	}
 
 	entry := c.root
-	for _, pkey := range Segments(key) {
-		// "prop.array[0]" => pkey = "array[0]"
-		if strings.HasSuffix(pkey, "]") {
-			// array index
-			pts := strings.Split(pkey, "[")
-			if len(pts) != 2 {
-				// invalid format, expects "prop.array[0]"
-				return nil
-			} else if entry.kind != ObjectKind || entry.value == nil {
-				// entry is not an object, so there cannot be a child of the array type
-				return nil
-			} else if arrEntry, ok := (entry.value.(map[string]*Entry))[pts[0]]; !ok {
-				// object does not exist
-				break
-			} else if arrEntry.kind != ArrayKind || arrEntry.value == nil {
-				// data type is not array or is empty array
-				return nil
-			} else if idx, err := strconv.Atoi(strings.TrimSuffix(pts[1], "]")); err != nil {
-				// index is not number
-				return nil
-			} else if idx < 0 {
-				// does not accept negative index
-				return nil
-			} else if arr := arrEntry.value.([]*Entry); (len(arr) - 1) < idx {
-				// array does not have an object with the given index
+	prefix := ""
+	for _, segment := range path {
+		if entry.expr != "" {
+			// whole reference to an array or object (Ex. "${server}")
+			c.expand(entry, prefix)
+		}
+		if segment.isIndex {
+			prefix += "[" + strconv.Itoa(segment.index) + "]"
+			// "prop.array[0]"
+			list, _ := entry.value.([]*Entry)
+			if entry.kind != ArrayKind || segment.appends || segment.index >= len(list) {
+				// data type is not array or array does not have an element with the given index
 				return nil
-			} else {
-				entry = arr[idx]
 			}
+			entry = list[segment.index]
 		} else if entry.kind != ObjectKind || entry.value == nil {
 			// entry is not an object, therefore there cannot be a child with the given key
 			return nil
-		} else if e, ok := (entry.value.(map[string]*Entry))[pkey]; !ok {
+		} else if e, ok := c.child(entry, segment.key); !ok {
 			return nil
 		} else {
+			prefix = joinKey(prefix, Escape(segment.key))
 			entry = e
 		}
 	}
@@ -204,23 +158,131 @@ func (c *Env) getEntryUnsafe(key string) *Entry {
 	return entry
 }
 
-// expand replaces ${var} or $var in the strings based on the mapping function.
-func (c *Env) expand(e *Entry) {
-	switch e.kind {
-	case StringKind:
-		if e.expr != "" && strings.IndexByte(e.value.(string), '$') >= 0 {
-			// replaces ${var} or $var in the string
-			e.value = os.Expand(e.expr, c.getStringUnsafe)
+// child of the object, ignoring case in case-insensitive mode
+func (c *Env) child(entry *Entry, key string) (*Entry, bool) {
+	obj := entry.value.(map[string]*Entry)
+	e, ok := obj[lookupKey(obj, key, c.caseInsensitive)]
+	return e, ok
+}
+
+// expand replaces the references (${var} or $var) in the strings of the entry with the key, see interpolator.
+// Returns the errors of the expressions.
+func (c *Env) expand(e *Entry, key string) error {
+	if e.expr != "" {
+		if !e.expanded {
+			// the expression references itself (Ex. a: ${b}, b: ${a})
+			for i, x := range c.expansions {
+				if x.entry == e {
+					var chain []string
+					for _, y := range c.expansions[i:] {
+						chain = append(chain, y.key)
+					}
+					return &ExpressionError{Key: key, Err: cycleError(chain)}
+				}
+			}
+
+			c.expansions = append(c.expansions, expansion{key: key, entry: e})
+			err := c.expandExpr(e)
+			c.expansions = c.expansions[:len(c.expansions)-1]
+
+			// the error of a referenced key is reported as an error of this key
+			var exprErr *ExpressionError
+			var cycle cycleError
+			if errors.As(err, &cycle) {
+				err = &ExpressionError{Key: key, Err: cycle.from(key)}
+			} else if errors.As(err, &exprErr) && exprErr.Key != key {
+				err = &ExpressionError{Key: key, Err: exprErr.Err}
+			} else if err != nil && exprErr == nil {
+				err = &ExpressionError{Key: key, Err: err}
+			}
+			e.expanded, e.exprErr = true, err
+			c.expanded = true
 		}
+		return e.exprErr
+	}
+
+	switch e.kind {
 	case ArrayKind:
-		for _, entry := range e.value.([]*Entry) {
-			c.expand(entry)
+		var errs []error
+		for i, entry := range e.value.([]*Entry) {
+			errs = append(errs, c.expand(entry, key+"["+strconv.Itoa(i)+"]"))
 		}
+		return errors.Join(errs...)
 	case ObjectKind:
-		for _, entry := range e.value.(map[string]*Entry) {
-			c.expand(entry)
+		var errs []error
+		value, _ := e.value.(map[string]*Entry)
+		for _, name := range sortedKeys(value) {
+			errs = append(errs, c.expand(value[name], joinKey(key, Escape(name))))
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// resetExpressions restores the expressions expanded since the last reset, see Entry.resetExpressions
+func (c *Env) resetExpressions() {
+	if c.expanded {
+		c.root.resetExpressions()
+		c.expanded = false
+	}
+}
+
+// cycleError the keys of a reference cycle, in order (Ex. a: ${b}, b: ${a} => [a b])
+type cycleError []string
+
+func (e cycleError) Error() string {
+	return "reference cycle " + strings.Join(append(append([]string{}, e...), e[0]), " -> ")
+}
+
+// from the cycle starting at the key, when it is part of the cycle
+func (e cycleError) from(key string) cycleError {
+	for i, k := range e {
+		if k == key {
+			return append(append(cycleError{}, e[i:]...), e[:i]...)
 		}
 	}
+	return e
+}
+
+// expandExpr sets the value of the entry to the expansion of its expression. A whole single reference
+// (Ex. "${server.port}") keeps the kind of the referenced value (number, bool, array or object).
+func (c *Env) expandExpr(e *Entry) error {
+	if ref, single := c.singleReference(e.expr); single {
+		_, exist, err := c.getValueUnsafe(ref)
+		if err != nil {
+			e.kind, e.value = StringKind, ""
+			return err
+		} else if exist {
+			target := c.getEntryUnsafe(ref).Clone()
+			e.kind, e.value = target.kind, target.value
+			return nil
+		}
+	}
+
+	p := &interpolator{lookup: c.reference, resolvers: c.resolvers, strict: c.strictInterpolation}
+	value, err := p.expand(e.expr)
+	e.kind, e.value = StringKind, value
+	return err
+}
+
+// singleReference the key of the expression when it is a single reference without operators ("${key}" or "$key")
+func (c *Env) singleReference(expr string) (string, bool) {
+	if len(expr) > 1 && expr[0] == '$' && strings.IndexFunc(expr[1:], func(r rune) bool { return r > 127 || !isNameChar(byte(r)) }) < 0 {
+		return expr[1:], true
+	} else if !strings.HasPrefix(expr, "${") || closingBrace(expr, 2) != len(expr)-1 {
+		return "", false
+	}
+
+	content := expr[2 : len(expr)-1]
+	switch key, op, _ := splitReference(content); op {
+	case "":
+		return key, true
+	case "-":
+		// a key containing "-" (Ex. "read-timeout")
+		key = strings.TrimSpace(content)
+		return key, c.getEntryUnsafe(key) != nil
+	}
+	return "", false
 }
 
 func (c *Env) lock(read bool) func() {
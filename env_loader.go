@@ -6,23 +6,44 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path"
+	"sort"
 	"strings"
 )
 
 type UnmarshalFn func(content []byte) (map[string]any, error)
 
-// SetFileSystem define a instância do FileSystem que será usado para carregamento
+// SetFileSystem define a instância do FileSystem que será usado para carregamento. nil restaura o padrão, o
+// diretório de trabalho.
 func (c *Env) SetFileSystem(fs http.FileSystem) {
-	c.fs = fs
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if fs == nil {
+		c.fs, c.fsDefault = defaultFileSystem(), true
+	} else {
+		c.fs, c.fsDefault = fs, false
+	}
+	// the file references are read again (Ex. "${file:/run/secrets/db}")
+	c.cache = map[string]*cacheEntry{}
+	c.resetExpressions()
 }
 
 // SetFilePaths define o caminho dos arquivos de configuração.
 func (c *Env) SetFilePaths(filePaths ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	c.filePaths = filePaths
 }
 
-// SetFileExt define o processador para essa extensão de arquivo. Usado para suportar .yaml, .toml, .xml
+// SetFileExt define o processador para essa extensão de arquivo. Usado para suportar .hcl (HclUnmarshal),
+// .jsonc e .json5 (Json5Unmarshal), .toml (TomlUnmarshal), .ini (IniUnmarshal), .properties
+// (PropertiesUnmarshal), .xml (XmlUnmarshal) ou formatos próprios. fn nil remove a extensão.
 func (c *Env) SetFileExt(ext string, fn UnmarshalFn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	if fn == nil {
 		delete(c.fileExts, ext)
 	} else {
@@ -30,40 +51,60 @@ func (c *Env) SetFileExt(ext string, fn UnmarshalFn) {
 	}
 }
 
+// SetDotEnvFiles define os arquivos ".env" carregados por LoadDotEnv, em ordem de precedência (os últimos
+// sobrescrevem os primeiros). "{profile}" é substituído por cada profile ativo (ver SetProfileKey).
+// Padrão: ".env", ".env.local", ".env.{profile}", ".env.{profile}.local"
+func (c *Env) SetDotEnvFiles(files ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.dotEnvFiles = files
+}
+
 // SetProfileKey define a key que identifica os arquivos de perfil de configuração.
 func (c *Env) SetProfileKey(profileKey string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	c.profileKey = profileKey
 }
 
 // Load initialize default settings. A variable is obtained respecting the order below.
 //
 // 1) command line arguments (starting with "--", e.g. --server.port=9000)
-// 2) DotEnv file variables ".env"
+// 2) DotEnv file variables (".env", ".env.local", ".env.{profile}", ".env.{profile}.local", see SetDotEnvFiles)
 // 3) Operating system variables
 // 4) Profile specific configuration (config-{dev|prod|test}.json)
 // 5) global config (config.json)
 // 6) Default config (cfg.New(DefaultConfig))
 func (c *Env) Load() error {
+	// keeps the state prior to loading, used by Reload
+	c.mutex.Lock()
+	c.base = c.root.Clone()
+	c.mutex.Unlock()
+
+	return c.load()
+}
+
+func (c *Env) load() error {
 
 	// 5) global config (config.json)
 	if err := c.LoadFiles(); err != nil {
 		return err
 	}
 
-	profiles := c.String(c.profileKey)
+	// settings with priority over profiles, the variables are applied to the config after the profiles, so array
+	// elements can be addressed by index (Ex. --servers[1].port=9000)
+	h := c.derive()
+	h.recordVars = true
 
-	// settings with priority over profiles
-	h := New()
-	h.fs = c.fs
-	h.fileExts = c.fileExts
-	h.filePaths = c.filePaths
-	h.profileKey = c.profileKey
+	profiles := c.String(h.profileKey)
 
 	// 3) Operating system variables
 	h.LoadOsEnv()
 
-	// 2) DotEnv file variables ".env"
-	if err := h.LoadDotEnv(); err != nil {
+	// 2) DotEnv file variables (.env, .env.local, .env.{profile}, .env.{profile}.local)
+	if err := h.loadDotEnv(profiles); err != nil {
 		return err
 	}
 
@@ -71,105 +112,242 @@ func (c *Env) Load() error {
 	h.LoadOsArgs(os.Args[1:])
 
 	// 4) Profile specific configuration (config-{dev|prod|test}.json)
-	newProfile := h.String(c.profileKey)
+	newProfile := h.String(h.profileKey)
 	if newProfile != "" && newProfile != profiles {
-		c.LoadObject(O{c.profileKey: newProfile})
+		c.LoadObject(O{h.profileKey: newProfile})
 	}
 	if err := c.LoadProfiles(); err != nil {
 		return err
 	}
-	c.Merge(h)
+	for _, batch := range h.varBatches {
+		c.loadVars(batch.vars, batch.origin)
+	}
+
+	// expressions (Ex. "${db.host:?required}")
+	if _, _, err := c.getValue(""); err != nil {
+		return err
+	}
+
+	if c.validateOnLoad {
+		return c.Validate()
+	}
 	return nil
 }
 
+// derive creates an empty Env with the same loading settings
+func (c *Env) derive() *Env {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	o := New()
+	o.fs, o.fsDefault = c.fs, c.fsDefault
+	o.fileExts = map[string]UnmarshalFn{}
+	for ext, fn := range c.fileExts {
+		o.fileExts[ext] = fn
+	}
+	o.filePaths = c.filePaths
+	o.profileKey = c.profileKey
+	o.dotEnvFiles = c.dotEnvFiles
+	o.envMapping = c.envMapping
+	o.caseInsensitive = c.caseInsensitive
+	o.strictInterpolation = c.strictInterpolation
+	o.mergeRules = append([]mergeRule{}, c.mergeRules...)
+	c.copyResolvers(o)
+	c.sensitiveMutex.RLock()
+	o.sensitive = append([]string{}, c.sensitive...)
+	c.sensitiveMutex.RUnlock()
+	return o
+}
+
 // LoadOsArgs will convert any command line option arguments (starting with ‘--’, e.g. --server.port=9000) to a
 // property and add it to the Env.
 //
-// Command line properties always take precedence over other property sources.
+// Command line properties always take precedence over other property sources. The value "null" removes the
+// property (Ex. --server.ssl=null).
 func (c *Env) LoadOsArgs(args []string) {
 	//args := os.Args[1:]
 
-	var environ []string
+	var vars []envVar
 	for _, arg := range args {
 		if strings.HasPrefix(arg, "--") && strings.IndexByte(arg, '=') > 1 {
 			// --server.port=9000
-			environ = append(environ, strings.TrimPrefix(arg, "--"))
+			key, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+			value = strings.TrimSpace(value)
+			vars = append(vars, envVar{key: strings.TrimSpace(key), value: value, null: value == "null"})
 		}
 	}
-	if len(environ) > 0 {
-		c.LoadEnviron(environ)
+	if len(vars) > 0 {
+		c.loadVars(vars, Origin{Source: SourceArgs})
 	}
 }
 
 // LoadOsEnv obtém todas as configurações do ambiente
 func (c *Env) LoadOsEnv() {
-	c.LoadEnviron(os.Environ())
+	var vars []envVar
+	for _, env := range os.Environ() {
+		if name, value, found := strings.Cut(env, "="); found {
+			vars = append(vars, envVar{key: c.envMapping.Key(strings.TrimSpace(name)), value: strings.TrimSpace(value)})
+		}
+	}
+	c.loadVars(vars, Origin{Source: SourceEnv})
 }
 
-// LoadDotEnv from https://github.com/joho/godotenv
+// LoadDotEnv carrega as variáveis dos arquivos ".env" (ver SetDotEnvFiles), compatível com
+// https://github.com/joho/godotenv (export, valores entre aspas, escapes, valores multi-linha e comentários).
+// Ver parseDotEnv.
 func (c *Env) LoadDotEnv() error {
-	if content, err := c.loadFile(".env"); err != nil {
+	return c.loadDotEnv("")
+}
+
+// loadDotEnv loads the dotenv files. The active profiles are read when the first "{profile}" file is found,
+// so they can be defined by the previous files, falling back to the profiles (comma separated)
+func (c *Env) loadDotEnv(fallbackProfiles string) error {
+	c.mutex.RLock()
+	files, profileKey := c.dotEnvFiles, c.profileKey
+	c.mutex.RUnlock()
+
+	var profiles []string
+	for _, file := range files {
+		if !strings.Contains(file, "{profile}") {
+			if err := c.loadDotEnvFile(file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if profiles == nil {
+			value := c.String(profileKey)
+			if value == "" {
+				value = fallbackProfiles
+			}
+			profiles = profileList(value)
+		}
+		for _, profile := range profiles {
+			if err := c.loadDotEnvFile(strings.ReplaceAll(file, "{profile}", profile)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Env) loadDotEnvFile(filepath string) error {
+	content, err := c.loadFile(filepath)
+	if err != nil || content == nil {
 		return err
-	} else if content != nil {
-		c.LoadEnviron(strings.Split(string(content), "\n"))
 	}
+
+	vars, err := parseDotEnv(content)
+	if err != nil {
+		slog.Error(
+			"[cfg] error processing file.",
+			slog.Any("error", err),
+			slog.String("filepath", filepath),
+		)
+		return err
+	}
+
+	for i := range vars {
+		vars[i].key = c.envMapping.Key(vars[i].key)
+	}
+	c.loadVars(vars, Origin{Source: SourceDotEnv, Path: filepath})
 	return nil
 }
 
+// dotEnvPaths the dotenv files of the profiles
+func (c *Env) dotEnvPaths(profiles []string) []string {
+	var files []string
+	for _, file := range c.dotEnvFiles {
+		if !strings.Contains(file, "{profile}") {
+			files = append(files, file)
+			continue
+		}
+		for _, profile := range profiles {
+			files = append(files, strings.ReplaceAll(file, "{profile}", profile))
+		}
+	}
+	return files
+}
+
+// LoadEnviron obtém as configurações a partir de uma lista "key=value"
 func (c *Env) LoadEnviron(environ []string) {
-	config := map[string]any{}
+	c.loadEnviron(environ, Origin{Source: SourceEnviron})
+}
+
+func (c *Env) loadEnviron(environ []string, origin Origin) {
+	var vars []envVar
 	for _, env := range environ {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			if strings.IndexByte(key, '[') >= 0 {
+		if key, value, found := strings.Cut(env, "="); found {
+			vars = append(vars, envVar{key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+		}
+	}
+	c.loadVars(vars, origin)
+}
+
+// loadVars merges the variables (config key = value) into the tree, in order. Variables with invalid keys
+// are ignored, the OS variables that cannot be mapped to a key are logged at debug level.
+func (c *Env) loadVars(vars []envVar, origin Origin) {
+	c.mutate(origin.Source, func() {
+		if c.recordVars {
+			// the invalid variables are reported when the recorded ones are applied
+			c.varBatches = append(c.varBatches, varBatch{vars: vars, origin: origin})
+		}
+		c.order++
+		origin.Order = c.order
+		for _, v := range vars {
+			path, err := parsePath(v.key)
+			if err != nil && origin.Source == SourceEnv {
+				// the process environment has unrelated variables (Ex. "SERVER__PORT" => "server..port")
+				if !c.recordVars {
+					slog.Debug("[cfg] ignoring variable.", slog.String("key", v.key), slog.Any("error", err))
+				}
 				continue
-			}
-			if strings.IndexByte(key, '.') < 0 {
-				config[key] = value
-			} else {
-				// @TODO: AQUI, GERAR O CONTEÚDO INTERNO USANDO A ESTRUTURA DA KEY
-				var lastKey string
-				parent := config
-				var current map[string]any
-				for _, k := range Segments(key) {
-					lastKey = k
-					if child, exist := parent[lastKey]; !exist {
-						parent[lastKey] = map[string]any{}
-					} else if _, isString := child.(string); isString {
-						parent[lastKey] = map[string]any{}
-					}
-					current = parent
-					parent = parent[lastKey].(map[string]any)
+			} else if err == nil {
+				o := origin
+				o.Line, o.Column = v.line, v.column
+				entry := ParseEntry(v.value)
+				if v.null {
+					entry = &Entry{kind: NullKind}
 				}
-				current[lastKey] = value
+				entry.stamp(v.key, o, nil)
+				err = c.root.setPath(path, entry, o, c.merger())
+			}
+			if err != nil && !c.recordVars {
+				slog.Warn("[cfg] ignoring variable.", slog.String("key", v.key), slog.Any("error", err))
 			}
 		}
-	}
-	c.LoadObject(config)
+	})
 }
 
 // LoadObject obtém as configurações a partir de um mapa em memória
 func (c *Env) LoadObject(config O) {
+	c.loadObject(config, Origin{Source: SourceObject}, nil)
+}
+
+// loadObject merges the config into the tree, positions (optional) are the line/column of the keys in the file.
+// The MergeDirective of the config defines the merge strategies of its arrays.
+func (c *Env) loadObject(config O, origin Origin, positions map[string]position) {
 	if config == nil {
 		return
 	}
+	config, rules := mergeDirective(config)
 	entries := &Entry{}
 	parseEntryMap(config, entries)
 
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	c.root.Merge(entries)
-	c.cache = map[string]*cacheEntry{}
+	c.mutate(origin.Source, func() {
+		c.order++
+		origin.Order = c.order
+		entries.stamp("", origin, positions)
+		c.merger(rules...).merge(c.root, entries, "")
+	})
 }
 
 // LoadFiles processa arquivos de configuração
 func (c *Env) LoadFiles() error {
-	for _, filepath := range c.filePaths {
-		for ext, fn := range c.fileExts {
-			if err := c.processFile(filepath+"."+ext, fn); err != nil {
+	s := c.derive() // the settings, read under the lock
+	for _, filepath := range s.filePaths {
+		for _, ext := range s.extensions() {
+			if err := c.processFile(filepath+"."+ext, s.fileExts[ext]); err != nil {
 				return err
 			}
 		}
@@ -177,21 +355,44 @@ func (c *Env) LoadFiles() error {
 	return nil
 }
 
+// extensions returns the registered file extensions, sorted. Files with the same name are loaded in this
+// order (Ex. config.json, config.yaml, config.yml). Internal use, on the settings of derive.
+func (c *Env) extensions() []string {
+	exts := make([]string, 0, len(c.fileExts))
+	for ext := range c.fileExts {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
 // Profiles get active profiles
 func (c *Env) Profiles() []string {
 	profiles := c.String(c.profileKey)
 	return strings.Split(profiles, ",")
 }
 
+// profileList splits the profiles (comma separated), ignoring empty names
+func profileList(value string) []string {
+	var profiles []string
+	for _, profile := range strings.Split(value, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
 // LoadProfiles processa arquivos de configuração (config.json)
 func (c *Env) LoadProfiles() error {
-	profiles := c.String(c.profileKey)
+	s := c.derive() // the settings, read under the lock
+	profiles := c.String(s.profileKey)
 	for _, profile := range strings.Split(profiles, ",") {
 		profile = strings.TrimSpace(profile)
-		for _, filepath := range c.filePaths {
-			for ext, fn := range c.fileExts {
+		for _, filepath := range s.filePaths {
+			for _, ext := range s.extensions() {
 				// load additional resources
-				if err := c.processFile(filepath+"-"+profile+"."+ext, fn); err != nil {
+				if err := c.processFile(filepath+"-"+profile+"."+ext, s.fileExts[ext]); err != nil {
 					return err
 				}
 			}
@@ -207,6 +408,8 @@ func (c *Env) processFile(filepath string, unmarshal UnmarshalFn) error {
 	} else if content == nil {
 		return nil
 	} else if config, errUnmarshal := unmarshal(content); errUnmarshal != nil {
+		// the values of the file are not in the config yet
+		errUnmarshal = c.redactError(errUnmarshal, c.sensitiveValues(content)...)
 		slog.Error(
 			"[cfg] error processing file.",
 			slog.Any("error", errUnmarshal),
@@ -214,26 +417,26 @@ func (c *Env) processFile(filepath string, unmarshal UnmarshalFn) error {
 		)
 		return errUnmarshal
 	} else {
-		c.LoadObject(config)
+		var positions map[string]position
+		if fn, exist := positionParsers[strings.TrimPrefix(path.Ext(filepath), ".")]; exist {
+			positions = fn(content)
+		}
+		c.loadObject(config, Origin{Source: SourceFile, Path: filepath}, positions)
 	}
 	return nil
 }
 
 func (c *Env) loadFile(filepath string) ([]byte, error) {
-	if c.fs == nil {
-		if fs, err := defaultFileSystem(); err != nil {
-			slog.Error(
-				"[cfg] could not create default FileSystem.",
-				slog.Any("error", err),
-				slog.String("filepath", filepath),
-			)
-			return nil, err
-		} else {
-			c.fs = fs
-		}
-	}
+	c.mutex.RLock()
+	fs := c.fs
+	c.mutex.RUnlock()
+
+	return readFile(fs, filepath)
+}
 
-	certFile, errFsRead := c.fs.Open(filepath)
+// readFile the content of the file, nil when it does not exist
+func readFile(fs http.FileSystem, filepath string) ([]byte, error) {
+	certFile, errFsRead := fs.Open(filepath)
 	if errFsRead != nil {
 		if errors.Is(errFsRead, os.ErrNotExist) {
 			return nil, nil
@@ -261,10 +464,7 @@ func (c *Env) loadFile(filepath string) ([]byte, error) {
 	}
 }
 
-func defaultFileSystem() (http.FileSystem, error) {
-	if pwd, err := os.Getwd(); err != nil {
-		return nil, err
-	} else {
-		return http.Dir(pwd), nil
-	}
+// defaultFileSystem the working directory, at the time of each read
+func defaultFileSystem() http.FileSystem {
+	return http.Dir(".")
 }
@@ -0,0 +1,53 @@
+package cfg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EnvMapping maps the names of environment variables to config keys, used by LoadOsEnv and LoadDotEnv.
+// Numeric segments are array indexes.
+//
+//	env.SetEnvMapping(cfg.EnvMapping{Prefix: "MYAPP_", Separator: "__", LowerCase: true})
+//	// MYAPP_SERVER__PORT=80       => server.port
+//	// MYAPP_SERVERS__0__HOST=a    => servers[0].host
+//	// MYAPP_DB__MAX_CONNECTIONS=5 => db.max_connections
+type EnvMapping struct {
+	Prefix    string // only variables with the prefix are mapped (without the prefix), the others are loaded as is
+	Separator string // separator of segments (Ex. "_" or "__"), empty keeps the names (dots are still segments)
+	LowerCase bool   // converts the names to lower case (Ex. "SERVER_PORT" => "server.port")
+}
+
+// SetEnvMapping define como os nomes das variáveis de ambiente (LoadOsEnv e LoadDotEnv) são convertidos em keys
+func (c *Env) SetEnvMapping(mapping EnvMapping) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.envMapping = mapping
+}
+
+// Key converts the name of the variable to a config key
+func (m EnvMapping) Key(name string) string {
+	if m.Prefix != "" {
+		if !strings.HasPrefix(name, m.Prefix) {
+			return name
+		}
+		name = strings.TrimPrefix(name, m.Prefix)
+	}
+	if m.LowerCase {
+		name = strings.ToLower(name)
+	}
+	if m.Separator == "" {
+		return name
+	}
+
+	var key string
+	for _, part := range strings.Split(name, m.Separator) {
+		if _, err := strconv.Atoi(part); err == nil && key != "" {
+			key += "[" + part + "]"
+		} else {
+			key = joinKey(key, part)
+		}
+	}
+	return key
+}
@@ -0,0 +1,111 @@
+package cfg
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEnvMapping_Key(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping EnvMapping
+		env     string
+		want    string
+	}{
+		{"default", EnvMapping{}, "SERVER_PORT", "SERVER_PORT"},
+		{"dotted", EnvMapping{}, "server.port", "server.port"},
+		{"separator", EnvMapping{Separator: "_", LowerCase: true}, "SERVER_PORT", "server.port"},
+		{"double separator", EnvMapping{Separator: "__", LowerCase: true}, "DB__MAX_CONNECTIONS", "db.max_connections"},
+		{"prefix", EnvMapping{Prefix: "MYAPP_", Separator: "_", LowerCase: true}, "MYAPP_SERVER_PORT", "server.port"},
+		{"without prefix", EnvMapping{Prefix: "MYAPP_", Separator: "_", LowerCase: true}, "HOME", "HOME"},
+		{"index", EnvMapping{Separator: "_", LowerCase: true}, "SERVERS_0_HOST", "servers[0].host"},
+		{"nested index", EnvMapping{Separator: "_"}, "MATRIX_1_0", "MATRIX[1][0]"},
+		{"case", EnvMapping{Separator: "_"}, "Server_Port", "Server.Port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mapping.Key(tt.env); got != tt.want {
+				t.Errorf("Key() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_LoadOsEnv_Mapping(t *testing.T) {
+	t.Setenv("MYAPP_SERVER_PORT", "9090")
+	t.Setenv("MYAPP_SERVERS_0_HOST", "a.example.com")
+	t.Setenv("MYAPP_SERVERS_1_HOST", "b.example.com")
+	t.Setenv("MYAPP_SERVERS_9_HOST", "ignored")
+
+	env := New(O{
+		"server":  O{"port": 80, "host": "localhost"},
+		"servers": []O{{"host": "a", "port": 1}},
+	})
+	env.SetEnvMapping(EnvMapping{Prefix: "MYAPP_", Separator: "_", LowerCase: true})
+	env.LoadOsEnv()
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"server.port", "9090"},
+		{"server.host", "localhost"},
+		{"servers[0].host", "a.example.com"},
+		{"servers[0].port", float64(1)},
+		{"servers[1].host", "b.example.com"},
+		{"servers[9].host", nil},
+	}
+	for _, tt := range tests {
+		if got := env.Get(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if got := env.Origin("server.port"); len(got) != 2 || got[0].Source != SourceEnv || got[1].Source != SourceDefault {
+		t.Errorf("Origin() = %v", got)
+	}
+}
+
+func TestEnv_LoadOsEnv_Unmappable(t *testing.T) {
+	t.Setenv("CFG_TEST__PORT", "80")
+
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	// "CFG_TEST__PORT" => "cfg.test..port", ignored without warnings
+	env := New()
+	env.SetEnvMapping(EnvMapping{Separator: "_", LowerCase: true})
+	env.LoadOsEnv()
+	if strings.Contains(buf.String(), "cfg.test..port") {
+		t.Errorf("LoadOsEnv() logged %s", buf.String())
+	}
+
+	env.LoadOsArgs([]string{"--cfg.test..port=80"})
+	if !strings.Contains(buf.String(), "cfg.test..port") {
+		t.Errorf("LoadOsArgs() must log the invalid key")
+	}
+}
+
+func TestEnv_LoadDotEnv_Mapping(t *testing.T) {
+	env := New(O{"db": O{"host": "localhost"}})
+	env.SetFileSystem(http.FS(fstest.MapFS{".env": {Data: []byte("DB__HOST=db\nDB__POOL__MAX_SIZE=10\n")}}))
+	env.SetEnvMapping(EnvMapping{Separator: "__", LowerCase: true})
+	if err := env.LoadDotEnv(); err != nil {
+		t.Fatalf("LoadDotEnv() error = %v", err)
+	}
+
+	if got := env.String("db.host"); got != "db" {
+		t.Errorf("String() = %v, want %v", got, "db")
+	}
+	if got := env.Int("db.pool.max_size"); got != 10 {
+		t.Errorf("Int() = %v, want %v", got, 10)
+	}
+	if got, want := env.Origin("db.pool.max_size"), []Origin{{Source: SourceDotEnv, Path: ".env", Line: 2, Column: 1, Order: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %v, want %v", got, want)
+	}
+}
@@ -1,8 +1,13 @@
 package cfg
 
 import (
+	"errors"
+	"net/http"
+	"os"
 	"reflect"
+	"sort"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -250,6 +255,139 @@ func TestEnv_Set(t *testing.T) {
 	}
 }
 
+func TestEnv_SetArray(t *testing.T) {
+	env := New(O{"servers": []any{O{"host": "a", "port": 80}}})
+
+	steps := []struct {
+		key   string
+		value any
+	}{
+		{"servers[0].host", "a1"},      // existing index
+		{"servers[1].host", "b"},       // index == len extends
+		{"servers[+].host", "c"},       // append
+		{"servers[-1].port", 82},       // append
+		{"servers[0].tags[0]", "x"},    // nested array
+		{"matrix[0][1].v", true},       // out of range, ignored
+		{"servers[9].host", "invalid"}, // out of range, ignored
+	}
+	for _, step := range steps {
+		env.Set(step.key, step.value)
+	}
+
+	want := []any{
+		map[string]any{"host": "a1", "port": float64(80), "tags": []any{"x"}},
+		map[string]any{"host": "b"},
+		map[string]any{"host": "c"},
+		map[string]any{"port": float64(82)},
+	}
+	if got := env.Get("servers"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+	if got := env.Get("servers[1].host"); got != "b" {
+		t.Errorf("Get() = %v, want b", got)
+	}
+	if got := env.Get("matrix"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+
+	env.LoadOsArgs([]string{"--servers[1].port=8081", "--servers[+].host=d"})
+	env.LoadEnviron([]string{"servers[0].tags[+]=y"})
+	if got := env.Strings("servers[0].tags"); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("Strings() = %v, want [x y]", got)
+	}
+	if got := env.Int("servers[1].port"); got != 8081 {
+		t.Errorf("Int() = %v, want 8081", got)
+	}
+	if got := env.String("servers[4].host"); got != "d" {
+		t.Errorf("String() = %v, want d", got)
+	}
+}
+
+func TestEnv_Load_SetArray(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "--servers[0].host=z", "--servers[1].port=9", "--list[+]=w", "--servers[9].host=x"}
+	t.Setenv("CFG_TEST_SERVERS_1_HOST", "c")
+
+	env := New()
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.json": {Data: []byte(`{"servers":[{"host":"a","port":1},{"host":"b","port":2}],"list":["x","y"]}`)},
+	}))
+	env.SetDotEnvFiles()
+	env.SetEnvMapping(EnvMapping{Prefix: "CFG_TEST_", Separator: "_", LowerCase: true})
+	if err := env.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []any{
+		map[string]any{"host": "z", "port": float64(1)},
+		map[string]any{"host": "c", "port": "9"},
+	}
+	if got := env.Get("servers"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+	if got := env.Strings("list"); !reflect.DeepEqual(got, []string{"x", "y", "w"}) {
+		t.Errorf("Strings() = %v, want [x y w]", got)
+	}
+	if got := env.Origin("servers[1].port"); len(got) != 2 || got[0].Source != SourceArgs {
+		t.Errorf("Origin() = %v", got)
+	}
+}
+
+func TestEnv_Delete(t *testing.T) {
+	env := New(O{
+		"db":      O{"host": "localhost", "password": "secret"},
+		"servers": []any{"a", "b", "c"},
+		"ssl":     O{"cert": "a.pem"},
+		"debug":   true,
+	})
+
+	// cached values are invalidated
+	if got := env.Get("db.password"); got != "secret" {
+		t.Fatalf("Get() = %v, want secret", got)
+	}
+
+	env.Delete("db.password")
+	env.Delete("servers[1]")
+	env.Delete("missing.key")
+	env.Set("debug", nil)
+
+	if got := env.Get("db.password"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+	if got := env.Get("db"); !reflect.DeepEqual(got, map[string]any{"host": "localhost"}) {
+		t.Errorf("Get() = %v, want map[host:localhost]", got)
+	}
+	if got := env.Strings("servers"); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("Strings() = %v, want [a c]", got)
+	}
+	if got := env.Get("debug"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+	if got := env.Get("missing"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+
+	// explicit null in a higher precedence source
+	env.LoadObject(O{"db": O{"host": nil, "port": 5432}})
+	if got := env.Get("db"); !reflect.DeepEqual(got, map[string]any{"port": float64(5432)}) {
+		t.Errorf("Get() = %v, want map[port:5432]", got)
+	}
+	env.LoadOsArgs([]string{"--ssl=null", "--servers[0]=null"})
+	if got := env.Get("ssl"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+	if got := env.Strings("servers"); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("Strings() = %v, want [c]", got)
+	}
+
+	// null values are not kept, the key does not exist
+	env.LoadObject(O{"cache": O{"ttl": nil}})
+	if got := env.Get("cache"); !reflect.DeepEqual(got, map[string]any{}) {
+		t.Errorf("Get() = %v, want map[]", got)
+	}
+}
+
 func TestEnv_Clone(t *testing.T) {
 	env := New(testConfig)
 	clone := env.Clone()
@@ -626,3 +764,94 @@ func TestEnv_Duration(t *testing.T) {
 		})
 	}
 }
+
+func TestEnv_Lookup(t *testing.T) {
+	env := New(O{
+		"port":     "80a",
+		"valid":    "80",
+		"bool":     "maybe",
+		"timeout":  "5x",
+		"duration": "5s",
+		"time":     "2023-10-10T10:00:00Z",
+	})
+
+	if _, err := env.LookupInt("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("LookupInt() error = %v, want %v", err, ErrKeyNotFound)
+	}
+
+	var convErr *ConversionError
+	if _, err := env.LookupInt("port"); !errors.As(err, &convErr) {
+		t.Errorf("LookupInt() error = %v, want *ConversionError", err)
+	} else if convErr.Key != "port" || convErr.Value != "80a" || convErr.Type != "int" {
+		t.Errorf("LookupInt() error = %+v", convErr)
+	}
+	if _, err := env.LookupFloat("port"); !errors.As(err, &convErr) {
+		t.Errorf("LookupFloat() error = %v, want *ConversionError", err)
+	}
+	if _, err := env.LookupBool("bool"); !errors.As(err, &convErr) {
+		t.Errorf("LookupBool() error = %v, want *ConversionError", err)
+	}
+	if _, err := env.LookupDuration("timeout"); !errors.As(err, &convErr) {
+		t.Errorf("LookupDuration() error = %v, want *ConversionError", err)
+	}
+	if _, err := env.LookupTime("valid"); !errors.As(err, &convErr) {
+		t.Errorf("LookupTime() error = %v, want *ConversionError", err)
+	}
+
+	if got, err := env.LookupInt("valid"); err != nil || got != 80 {
+		t.Errorf("LookupInt() = %v, %v, want %v", got, err, 80)
+	}
+	if got, err := env.LookupDuration("duration"); err != nil || got != 5*time.Second {
+		t.Errorf("LookupDuration() = %v, %v, want %v", got, err, 5*time.Second)
+	}
+	if got, err := env.LookupTime("time"); err != nil || !got.Equal(time.Date(2023, 10, 10, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("LookupTime() = %v, %v", got, err)
+	}
+
+	// getters fall back to the default value
+	if got := env.Int("port", 8080); got != 8080 {
+		t.Errorf("Int() = %v, want %v", got, 8080)
+	}
+}
+
+func TestEnv_SetCaseInsensitive(t *testing.T) {
+	env := New(O{"db": O{"host": "localhost", "port": 5432}})
+	env.SetCaseInsensitive(true)
+
+	env.LoadEnviron([]string{"DB.HOST=db"})
+	env.Set("Db.User", "admin")
+	env.Set("DB.USER", "root")
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"db.host", "db"},
+		{"DB.HOST", "db"},
+		{"Db.Port", float64(5432)},
+		{"db.user", "root"},
+	}
+	for _, tt := range tests {
+		if got := env.Get(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	// the casing of the first loaded key is kept
+	keys := env.Keys("DB")
+	sort.Strings(keys)
+	if want := []string{"User", "host", "port"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() = %v, want %v", keys, want)
+	}
+
+	// existing keys are merged when enabled, the most recent value wins
+	env = New(O{"db": O{"host": "localhost"}})
+	env.LoadEnviron([]string{"DB.HOST=db", "DB.PORT=5432"})
+	if got := env.Get("db.host"); got != "localhost" {
+		t.Errorf("Get() = %v, want %v", got, "localhost")
+	}
+	env.SetCaseInsensitive(true)
+	if got, want := env.Get("DB"), map[string]any{"host": "db", "PORT": "5432"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrKeyNotFound is returned by the Lookup methods when the key does not exist
+var ErrKeyNotFound = errors.New("cfg: key not found")
+
+// ConversionError is returned when a value cannot be converted to the requested type
+type ConversionError struct {
+	Key   string // Config key
+	Value any    // Raw value
+	Type  string // Target type
+	Err   error  // Underlying error
+}
+
+func (e *ConversionError) Error() string {
+	msg := fmt.Sprintf("cfg: cannot convert key %q (value %#v) to %s", e.Key, e.Value, e.Type)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+func keyNotFound(key string) error {
+	return fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+}
+
+// ValidationError a rule violated by the value of the key
+type ValidationError struct {
+	Key string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors lists every violated rule, returned by Validate and Bind
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "cfg: invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	var errs []error
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ExpressionError an expression (Ex. "${db.host:?required}") of the value of the key that cannot be expanded,
+// see SetStrictInterpolation
+type ExpressionError struct {
+	Key string // Config key
+	Err error  // Underlying error
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("cfg: cannot expand key %q: %v", e.Key, e.Err)
+}
+
+func (e *ExpressionError) Unwrap() error {
+	return e.Err
+}
+
+// SyntaxError describes a malformed config file, returned by the built-in UnmarshalFn
+type SyntaxError struct {
+	Format string // file format (Ex. "toml")
+	Line   int    // line of the error, starting at 1
+	Column int    // column of the error, starting at 1
+	Msg    string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("cfg: %s: syntax error at line %d, column %d: %s", e.Format, e.Line, e.Column, e.Msg)
+}
+
+// syntaxError creates a SyntaxError at the offset of the content
+func syntaxError(format string, content []byte, offset int, msg string, args ...any) error {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	line, column := lineColumn(content, offset)
+	return &SyntaxError{Format: format, Line: line, Column: column, Msg: fmt.Sprintf(msg, args...)}
+}
@@ -0,0 +1,79 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ConverterFn converts a raw config value (bool, float64, string, []any or map[string]any)
+type ConverterFn func(value any) (any, error)
+
+var (
+	convertersMutex sync.RWMutex
+	converters      = map[reflect.Type]ConverterFn{}
+)
+
+// RegisterConverter defines the function used to convert raw config values into T.
+// Registered converters are used by GetAs, MustGetAs and Bind. A nil fn removes the converter.
+//
+//	cfg.RegisterConverter(func(v any) (net.IP, error) { ... })
+func RegisterConverter[T any](fn func(value any) (T, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	convertersMutex.Lock()
+	defer convertersMutex.Unlock()
+
+	if fn == nil {
+		delete(converters, t)
+	} else {
+		converters[t] = func(value any) (any, error) {
+			return fn(value)
+		}
+	}
+}
+
+func converterOf(t reflect.Type) (ConverterFn, bool) {
+	convertersMutex.RLock()
+	defer convertersMutex.RUnlock()
+
+	fn, exist := converters[t]
+	return fn, exist
+}
+
+// GetAs get a config value converted to T, using the same rules as Bind
+// (int*, uint*, float*, bool, string, time.Duration, time.Time, []T, map[string]T,
+// structs and any type registered with RegisterConverter).
+//
+// When the key does not exist, returns the default value (if informed) or ErrKeyNotFound.
+func GetAs[T any](e *Env, key string, def ...T) (T, error) {
+	var out T
+	if len(def) > 0 {
+		out = def[0]
+	}
+
+	value, exist, err := e.getValue(key)
+	if err != nil {
+		return out, err
+	} else if !exist || value == nil {
+		if len(def) > 0 {
+			return out, nil
+		}
+		return out, keyNotFound(key)
+	}
+
+	var target T
+	if err := e.bind(key, value, reflect.ValueOf(&target).Elem(), ""); err != nil {
+		return out, err
+	}
+	return target, nil
+}
+
+// MustGetAs same as GetAs, but panics if the value is missing or invalid.
+func MustGetAs[T any](e *Env, key string, def ...T) T {
+	out, err := GetAs[T](e, key, def...)
+	if err != nil {
+		panic(fmt.Sprintf("cfg: MustGetAs(%q): %v", key, err))
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetAs(t *testing.T) {
+	env := New(testConfig)
+
+	tests := []struct {
+		name string
+		get  func() (any, error)
+		want any
+	}{
+		{"int", func() (any, error) { return GetAs[int](env, "int.neg") }, -1},
+		{"int64", func() (any, error) { return GetAs[int64](env, "string.value") }, int64(1)},
+		{"uint16", func() (any, error) { return GetAs[uint16](env, "uint16.pos") }, uint16(1)},
+		{"float32", func() (any, error) { return GetAs[float32](env, "float64.neg") }, float32(-1)},
+		{"bool", func() (any, error) { return GetAs[bool](env, "bool.true") }, true},
+		{"string", func() (any, error) { return GetAs[string](env, "string.template") }, "1"},
+		{"duration", func() (any, error) { return GetAs[time.Duration](env, "duration.milis") }, 300 * time.Millisecond},
+		{"[]int", func() (any, error) { return GetAs[[]int](env, "array.int") }, []int{-1, 0, 1}},
+		{"[]string", func() (any, error) { return GetAs[[]string](env, "array.string") }, []string{"", "1", "1"}},
+		{"map[string]int", func() (any, error) { return GetAs[map[string]int](env, "int") }, map[string]int{"neg": -1, "zero": 0, "pos": 1}},
+		{"default", func() (any, error) { return GetAs[int](env, "missing", 8) }, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if err != nil {
+				t.Fatalf("GetAs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := GetAs[int](env, "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("GetAs() error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if _, err := GetAs[int](env, "map.key"); err == nil {
+		t.Errorf("GetAs() expected conversion error")
+	}
+}
+
+func TestMustGetAs(t *testing.T) {
+	env := New(O{"port": "80a"})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustGetAs() expected panic")
+		}
+	}()
+	MustGetAs[int](env, "port")
+}
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(func(value any) (net.IP, error) {
+		ip := net.ParseIP(fmt.Sprint(value))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip %v", value)
+		}
+		return ip, nil
+	})
+	defer RegisterConverter[net.IP](nil)
+
+	env := New(O{"server": O{"ip": "10.0.0.1", "invalid": "10.0.0"}})
+
+	if got, err := GetAs[net.IP](env, "server.ip"); err != nil || !got.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("GetAs() = %v, %v", got, err)
+	}
+	if _, err := GetAs[net.IP](env, "server.invalid"); err == nil {
+		t.Errorf("GetAs() expected conversion error")
+	}
+
+	var server struct {
+		IP net.IP `cfg:"ip"`
+	}
+	if err := env.Bind("server", &server); err != nil || !server.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Bind() = %v, %v", server.IP, err)
+	}
+}
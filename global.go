@@ -1,6 +1,8 @@
 package cfg
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"time"
 )
@@ -23,21 +25,64 @@ func TimeLayout(key string, layout string, def ...time.Time) time.Time {
 	return c.TimeLayout(key, layout, def...)
 }
 
-func SetString(key string, value string) { c.Set(key, value) }
-func Clone() *Env                        { return c.Clone() }
-func Merge(src *Env)                     { c.Merge(src) }
-
-func SetFileSystem(fs http.FileSystem)      { c.SetFileSystem(fs) }
-func SetFilePaths(filePaths ...string)      { c.SetFilePaths(filePaths...) }
-func SetFileExt(ext string, fn UnmarshalFn) { c.SetFileExt(ext, fn) }
-func SetProfileKey(profileKey string)       { c.SetProfileKey(profileKey) }
-
-func Load() error                  { return c.Load() }
-func LoadOsArgs(args []string)     { c.LoadOsArgs(args) }
-func LoadOsEnv()                   { c.LoadOsEnv() }
-func LoadDotEnv() error            { return c.LoadDotEnv() }
-func LoadEnviron(environ []string) { c.LoadEnviron(environ) }
-func LoadObject(config O)          { c.LoadObject(config) }
-func LoadFiles() error             { return c.LoadFiles() }
-func LoadProfiles() error          { return c.LoadProfiles() }
-func Global() *Env                 { return c }
+func LookupBool(key string) (bool, error)              { return c.LookupBool(key) }
+func LookupInt(key string) (int, error)                { return c.LookupInt(key) }
+func LookupFloat(key string) (float64, error)          { return c.LookupFloat(key) }
+func LookupString(key string) (string, error)          { return c.LookupString(key) }
+func LookupDuration(key string) (time.Duration, error) { return c.LookupDuration(key) }
+func LookupTime(key string) (time.Time, error)         { return c.LookupTime(key) }
+func LookupTimeLayout(key string, layout string) (time.Time, error) {
+	return c.LookupTimeLayout(key, layout)
+}
+
+func SecretOf(key string, def ...string) Secret { return c.Secret(key, def...) }
+func MarkSensitive(patterns ...string)          { c.MarkSensitive(patterns...) }
+func IsSensitive(key string) bool               { return c.IsSensitive(key) }
+
+func Bind(prefix string, dst any) error { return c.Bind(prefix, dst) }
+func Unmarshal(dst any) error           { return c.Unmarshal(dst) }
+
+func Rule(key string, rules ...RuleFn)     { c.Rule(key, rules...) }
+func Validate() error                      { return c.Validate() }
+func SetValidateOnLoad(validate bool)      { c.SetValidateOnLoad(validate) }
+func ValidateSchema(filepath string) error { return c.ValidateSchema(filepath) }
+func Schema() O                            { return c.Schema() }
+
+func SetString(key string, value string)       { c.Set(key, value) }
+func Delete(key string)                        { c.Delete(key) }
+func Clone() *Env                              { return c.Clone() }
+func Merge(src *Env)                           { c.Merge(src) }
+func OriginOf(key string) []Origin             { return c.Origin(key) }
+func Dump(w io.Writer, opts DumpOptions) error { return c.Dump(w, opts) }
+func Explain(key string) string                { return c.Explain(key) }
+func Marshal(format string, opts ...MarshalOption) ([]byte, error) {
+	return c.Marshal(format, opts...)
+}
+func OnChange(prefix string, fn func(ev ChangeEvent)) (unsubscribe func()) {
+	return c.OnChange(prefix, fn)
+}
+
+func SetFileSystem(fs http.FileSystem)                        { c.SetFileSystem(fs) }
+func SetFilePaths(filePaths ...string)                        { c.SetFilePaths(filePaths...) }
+func SetFileExt(ext string, fn UnmarshalFn)                   { c.SetFileExt(ext, fn) }
+func SetProfileKey(profileKey string)                         { c.SetProfileKey(profileKey) }
+func SetMarshalFn(format string, fn MarshalFn)                { c.SetMarshalFn(format, fn) }
+func SetDotEnvFiles(files ...string)                          { c.SetDotEnvFiles(files...) }
+func SetEnvMapping(mapping EnvMapping)                        { c.SetEnvMapping(mapping) }
+func SetCaseInsensitive(enabled bool)                         { c.SetCaseInsensitive(enabled) }
+func SetMergeStrategy(pattern string, strategy MergeStrategy) { c.SetMergeStrategy(pattern, strategy) }
+func SetStrictInterpolation(strict bool)                      { c.SetStrictInterpolation(strict) }
+func RegisterResolver(scheme string, fn ResolverFn)           { c.RegisterResolver(scheme, fn) }
+
+func Load() error                             { return c.Load() }
+func Reload() error                           { return c.Reload() }
+func Watch(ctx context.Context) error         { return c.Watch(ctx) }
+func SetWatchInterval(interval time.Duration) { c.SetWatchInterval(interval) }
+func LoadOsArgs(args []string)                { c.LoadOsArgs(args) }
+func LoadOsEnv()                              { c.LoadOsEnv() }
+func LoadDotEnv() error                       { return c.LoadDotEnv() }
+func LoadEnviron(environ []string)            { c.LoadEnviron(environ) }
+func LoadObject(config O)                     { c.LoadObject(config) }
+func LoadFiles() error                        { return c.LoadFiles() }
+func LoadProfiles() error                     { return c.LoadProfiles() }
+func Global() *Env                            { return c }
@@ -0,0 +1,223 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// expansion an expression being expanded, used to detect reference cycles
+type expansion struct {
+	key   string
+	entry *Entry
+}
+
+// interpolator expands the expressions of the values, shell style:
+//
+//	$key or ${key}      value of the key
+//	${key:-default}     default when the key does not exist or is empty (${key-default} only when it does not exist)
+//	${key:?message}     error when the key does not exist or is empty (${key?message} only when it does not exist)
+//	${key:+alternate}   alternate when the key exists and is not empty (${key+alternate} when it exists)
+//	${scheme:arg}       value of the resolver of the scheme (Ex. ${env:HOME}), only the colon operators apply
+//	$$                  literal "$"
+//
+// The keys, default, message and alternate words are also expanded (Ex. ${db.port:-${DB_PORT:-5432}}).
+type interpolator struct {
+	lookup    func(key string) (value string, exist bool, err error)
+	resolvers map[string]ResolverFn // by scheme, see RegisterResolver
+	strict    bool                  // unresolved references (without default) are errors
+}
+
+// expand replaces the references of the expression
+func (p *interpolator) expand(expr string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(expr); {
+		if expr[i] != '$' || i+1 == len(expr) {
+			sb.WriteByte(expr[i])
+			i++
+			continue
+		}
+
+		switch next := expr[i+1]; {
+		case next == '$':
+			// "$$" is a literal "$"
+			sb.WriteByte('$')
+			i += 2
+		case next == '{':
+			end := closingBrace(expr, i+2)
+			if end < 0 {
+				// unterminated, kept as is
+				sb.WriteString(expr[i:])
+				return sb.String(), nil
+			}
+			value, err := p.reference(expr[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+			i = end + 1
+		case isNameChar(next):
+			// $name, letters, digits and "_"
+			end := i + 1
+			for end < len(expr) && isNameChar(expr[end]) {
+				end++
+			}
+			value, err := p.reference(expr[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+			i = end
+		default:
+			sb.WriteByte('$')
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+// reference the value of the reference (content of "${...}"), applying the operator
+func (p *interpolator) reference(content string) (string, error) {
+	key, op, word := splitReference(content)
+	if scheme, _, found := strings.Cut(strings.TrimSpace(content), ":"); found && p.resolvers[scheme] != nil {
+		// the arguments of the resolvers can contain "-", "?" and "+"
+		key, op, word = strings.TrimSpace(content), "", ""
+		if i := operatorIndex(content, true); i >= 0 {
+			key, op, word = strings.TrimSpace(content[:i]), content[i:i+2], content[i+2:]
+		}
+	} else if op == "-" {
+		// a key containing "-" (Ex. "read-timeout") takes precedence over the operator
+		if value, exist, err := p.lookup(strings.TrimSpace(content)); err != nil || exist {
+			return value, err
+		}
+		if p.strict && strings.IndexByte(key, '$') < 0 {
+			// a missing key containing "-" (Ex. a typo "${read-timeoutt}") is not a default value
+			if _, exist, err := p.lookup(key); err != nil {
+				return "", err
+			} else if !exist {
+				return "", fmt.Errorf("unresolved reference %q", strings.TrimSpace(content))
+			}
+		}
+	}
+
+	if strings.IndexByte(key, '$') >= 0 {
+		// Ex. ${file:${SECRETS_DIR}/db_password}
+		var err error
+		if key, err = p.expand(key); err != nil {
+			return "", err
+		}
+	}
+
+	value, exist, err := p.resolve(key)
+	var missing *missingError
+	if errors.As(err, &missing) && op != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch op {
+	case ":-", "-":
+		if !exist || (op == ":-" && value == "") {
+			return p.expand(word)
+		}
+	case ":?", "?":
+		if !exist || (op == ":?" && value == "") {
+			msg, err := p.expand(word)
+			if err != nil {
+				return "", err
+			} else if msg == "" && op == ":?" {
+				msg = "not set or empty"
+			} else if msg == "" {
+				msg = "not set"
+			}
+			return "", errors.New(key + ": " + msg)
+		}
+	case ":+", "+":
+		if exist && (op == "+" || value != "") {
+			return p.expand(word)
+		}
+		return "", nil
+	default:
+		if !exist && p.strict {
+			return "", fmt.Errorf("unresolved reference %q", key)
+		}
+	}
+	return value, nil
+}
+
+// resolve the value of the key, using the resolver of its scheme (Ex. "env:HOME") if registered
+func (p *interpolator) resolve(key string) (string, bool, error) {
+	scheme, arg, found := strings.Cut(key, ":")
+	if fn := p.resolvers[scheme]; found && fn != nil {
+		value, err := fn(arg)
+		var missing *missingError
+		if errors.As(err, &missing) {
+			return "", false, fmt.Errorf("resolver %q: %w", scheme, err)
+		} else if errors.Is(err, ErrKeyNotFound) {
+			return "", false, nil
+		} else if err != nil {
+			// the argument is not part of the message, it can be a secret (Ex. base64)
+			return "", false, fmt.Errorf("resolver %q: %w", scheme, err)
+		}
+		return value, true, nil
+	}
+	return p.lookup(key)
+}
+
+// splitReference splits the content of "${...}" into key, operator and word. The operator is the first ":-",
+// ":?" or ":+" outside nested expressions, otherwise the first "-", "?" or "+".
+func splitReference(content string) (key, op, word string) {
+	if i := operatorIndex(content, true); i >= 0 {
+		return strings.TrimSpace(content[:i]), content[i : i+2], content[i+2:]
+	} else if i = operatorIndex(content, false); i >= 0 {
+		return strings.TrimSpace(content[:i]), content[i : i+1], content[i+1:]
+	}
+	return strings.TrimSpace(content), "", ""
+}
+
+// operatorIndex the index of the first operator outside nested expressions, with colon (":-") or without ("-")
+func operatorIndex(content string, colon bool) int {
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '$' && i+1 < len(content) && content[i+1] == '{':
+			if end := closingBrace(content, i+2); end >= 0 {
+				i = end
+			}
+		case colon && c == ':' && i+1 < len(content) && strings.IndexByte("-?+", content[i+1]) >= 0:
+			return i
+		case !colon && (c == '-' || c == '?' || c == '+'):
+			if c != '?' && i > 0 && content[i-1] == '[' {
+				// append index "[+]" or "[-1]"
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// closingBrace the index of the "}" that closes the expression started before start, -1 if not found
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
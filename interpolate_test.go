@@ -0,0 +1,256 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInterpolator_expand(t *testing.T) {
+	values := map[string]string{
+		"host":         "localhost",
+		"empty":        "",
+		"db.port":      "5432",
+		"read-timeout": "5s",
+		"servers[0]":   "a",
+	}
+	p := &interpolator{lookup: func(key string) (string, bool, error) {
+		value, exist := values[key]
+		return value, exist, nil
+	}}
+
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "plain", false},
+		{"$host:${db.port}", "localhost:5432", false},
+		{"${missing}", "", false},
+		{"$$host $${host} $", "$host ${host} $", false},
+		{"${read-timeout}", "5s", false},
+		{"${servers[0]}", "a", false},
+		{"${missing:-8080}", "8080", false},
+		{"${empty:-default}", "default", false},
+		{"${empty-default}", "", false},
+		{"${missing-default}", "default", false},
+		{"${host:-default}", "localhost", false},
+		{"${missing:-${db.port:-0}}", "5432", false},
+		{"${missing:-${other:-x}y}", "xy", false},
+		{"${host:+alt}", "alt", false},
+		{"${empty:+alt}", "", false},
+		{"${empty+alt}", "alt", false},
+		{"${missing+alt}", "", false},
+		{"${host:?required}", "localhost", false},
+		{"${empty?required}", "", false},
+		{"${empty:?required}", "", true},
+		{"${missing?}", "", true},
+		{"${missing:?${host} is required}", "", true},
+		{"${unterminated", "${unterminated", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.expand(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := p.expand("${missing:?${host} is required}"); err == nil || err.Error() != "missing: localhost is required" {
+		t.Errorf("expand() error = %v, want \"missing: localhost is required\"", err)
+	}
+
+	p.strict = true
+	if _, err := p.expand("${missing}"); err == nil {
+		t.Errorf("expand() must fail in strict mode")
+	}
+	if got, err := p.expand("${missing:-x}"); err != nil || got != "x" {
+		t.Errorf("expand() = %q, %v, want x", got, err)
+	}
+}
+
+func TestEnv_SetStrictInterpolation(t *testing.T) {
+	env := New(O{
+		"url":  "http://${host:-localhost}:${port}",
+		"pass": "${DB_PASS:?must be set}",
+	})
+
+	if got := env.String("url"); got != "http://localhost:" {
+		t.Errorf("String() = %q, want http://localhost:", got)
+	}
+	_, err := env.LookupString("pass")
+	var exprErr *ExpressionError
+	if !errors.As(err, &exprErr) || exprErr.Key != "pass" {
+		t.Fatalf("LookupString() error = %v, want *ExpressionError", err)
+	}
+	if want := `cfg: cannot expand key "pass": DB_PASS: must be set`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	env.SetStrictInterpolation(true)
+	if _, err := env.LookupString("url"); !errors.As(err, &exprErr) || exprErr.Key != "url" {
+		t.Errorf("LookupString() error = %v, want *ExpressionError", err)
+	}
+
+	env.Set("port", 80)
+	env.Set("DB_PASS", "secret")
+	if got, err := env.LookupString("url"); err != nil || got != "http://localhost:80" {
+		t.Errorf("LookupString() = %q, %v, want http://localhost:80", got, err)
+	}
+
+	// a missing key containing "-" is not a default value
+	env.Set("read-timeout", "5s")
+	env.Set("timeout", "${read-timeoutt}")
+	if _, err := env.LookupString("timeout"); !errors.As(err, &exprErr) {
+		t.Errorf("LookupString() error = %v, want *ExpressionError", err)
+	}
+	env.Set("timeout", "${port-8080}")
+	if got, err := env.LookupString("timeout"); err != nil || got != "80" {
+		t.Errorf("LookupString() = %q, %v, want 80", got, err)
+	}
+
+	// the references are checked by Load
+	env = New()
+	env.SetFileSystem(http.FS(fstest.MapFS{"config.json": {Data: []byte(`{"a": {"b": "${c:?required}"}}`)}}))
+	env.SetDotEnvFiles()
+	if err := env.Load(); !errors.As(err, &exprErr) || exprErr.Key != "a.b" {
+		t.Errorf("Load() error = %v, want *ExpressionError", err)
+	}
+}
+
+func TestEnv_expandEscape(t *testing.T) {
+	// "$$" is a literal "$" in the values of all sources, not only dotenv files
+	env := New(O{"host": "localhost", "default": "$$host"})
+	env.SetFileSystem(http.FS(fstest.MapFS{"config.json": {Data: []byte(`{"file": "$${host} ${host}"}`)}}))
+	env.SetDotEnvFiles()
+	if err := env.Load(); err != nil {
+		t.Fatal(err)
+	}
+	env.Set("price", "$$5")
+	env.LoadOsArgs([]string{"--arg=$$$host"})
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"default", "$host"},
+		{"file", "${host} localhost"},
+		{"price", "$5"},
+		{"arg", "$localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := env.String(tt.key); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_expandCycle(t *testing.T) {
+	env := New(O{
+		"a":    "${b}",
+		"b":    "x-${c.d}",
+		"c":    O{"d": "$a"},
+		"self": "${self:-default}",
+		"ok":   "${c.e:-fine}",
+	})
+
+	_, err := env.LookupString("a")
+	var exprErr *ExpressionError
+	if !errors.As(err, &exprErr) {
+		t.Fatalf("LookupString() error = %v, want *ExpressionError", err)
+	}
+	if want := `cfg: cannot expand key "a": reference cycle a -> b -> c.d -> a`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	// the other keys of the cycle report their own key
+	_, err = env.LookupString("c.d")
+	if !errors.As(err, &exprErr) || exprErr.Key != "c.d" {
+		t.Fatalf("LookupString() error = %v, want *ExpressionError of c.d", err)
+	}
+	if want := `cfg: cannot expand key "c.d": reference cycle c.d -> a -> b -> c.d`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if _, err := env.LookupString("self"); err == nil {
+		t.Errorf("LookupString() must fail")
+	}
+
+	// the error is logged once, for the key read
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	New(O{"a": "${b}", "b": "${a}"}).String("a")
+	if got := strings.Count(buf.String(), "level=ERROR"); got != 1 {
+		t.Errorf("logged %d errors, want 1: %s", got, buf.String())
+	}
+	if got := env.String("ok"); got != "fine" {
+		t.Errorf("String() = %q, want fine", got)
+	}
+}
+
+func TestEnv_expandTyped(t *testing.T) {
+	env := New(O{
+		"server":  O{"port": 8080, "tls": true, "hosts": []any{"a", "b"}},
+		"port":    "${server.port}",
+		"tls":     "$tls_enabled",
+		"hosts":   "${server.hosts}",
+		"backup":  "${server}",
+		"address": "localhost:${server.port}",
+		"timeout": "${read-timeout}",
+	})
+	env.Set("tls_enabled", false)
+	env.Set("read-timeout", 5)
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"port", float64(8080)},
+		{"tls", false},
+		{"hosts", []any{"a", "b"}},
+		{"backup", map[string]any{"port": float64(8080), "tls": true, "hosts": []any{"a", "b"}}},
+		{"backup.hosts[1]", "b"},
+		{"address", "localhost:8080"},
+		{"timeout", float64(5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := env.Get(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// the keys of a whole reference not read before
+	fresh := New(O{"db": O{"host": "localhost"}, "backup": "${db}", "list": []any{"a", "b"}, "copy": "${list}"})
+	if got := fresh.Keys("backup"); !reflect.DeepEqual(got, []string{"host"}) {
+		t.Errorf("Keys() = %v, want [host]", got)
+	}
+	if got := fresh.Keys("copy"); !reflect.DeepEqual(got, []string{"[0]", "[1]"}) {
+		t.Errorf("Keys() = %v, want [[0] [1]]", got)
+	}
+
+	if got := env.Int("port"); got != 8080 {
+		t.Errorf("Int() = %v, want 8080", got)
+	}
+	var hosts []string
+	if err := env.Bind("hosts", &hosts); err != nil || !reflect.DeepEqual(hosts, []string{"a", "b"}) {
+		t.Errorf("Bind() = %v, %v, want [a b]", hosts, err)
+	}
+
+	// the reference follows the changes of the referenced value
+	env.Set("server.port", 9090)
+	if got := env.Get("backup.port"); got != float64(9090) {
+		t.Errorf("Get() = %v, want 9090", got)
+	}
+}
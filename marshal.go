@@ -0,0 +1,163 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalFn serializes a config, inverse of UnmarshalFn
+type MarshalFn func(config map[string]any) ([]byte, error)
+
+// MarshalOption options of Env.Marshal
+type MarshalOption func(o *marshalOptions)
+
+type marshalOptions struct {
+	prefix   string
+	raw      bool
+	redacted bool
+	mask     []string
+}
+
+// MarshalPrefix serializes only the object of the key
+func MarshalPrefix(prefix string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.prefix = prefix
+	}
+}
+
+// MarshalRaw keeps the expressions (Ex. "${app.name}") instead of the expanded values
+func MarshalRaw() MarshalOption {
+	return func(o *marshalOptions) {
+		o.raw = true
+	}
+}
+
+// MarshalRedacted replaces the values of sensitive keys (see MarkSensitive) and of the keys matching the
+// patterns (see MatchKey) with MaskedValue
+func MarshalRedacted(patterns ...string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.redacted = true
+		o.mask = append(o.mask, patterns...)
+	}
+}
+
+// SetMarshalFn define o serializador para esse formato, usado por Marshal. fn nil remove o formato.
+func (c *Env) SetMarshalFn(format string, fn MarshalFn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if fn == nil {
+		delete(c.marshalFns, format)
+	} else {
+		c.marshalFns[format] = fn
+	}
+}
+
+// Marshal serializes the effective (merged) config in the format ("json", "yaml", "yml", "toml" or
+// registered by SetMarshalFn).
+//
+//	data, err := env.Marshal("yaml", cfg.MarshalPrefix("db"), cfg.MarshalRedacted())
+func (c *Env) Marshal(format string, opts ...MarshalOption) ([]byte, error) {
+	o := &marshalOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c.mutex.Lock()
+	marshal, exist := c.marshalFns[format]
+	if !exist {
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("cfg: unknown marshal format %q", format)
+	}
+
+	entry := c.root
+	if o.prefix != "" {
+		entry = c.getEntryUnsafe(o.prefix)
+	}
+	if entry == nil {
+		c.mutex.Unlock()
+		return nil, keyNotFound(o.prefix)
+	} else if entry.kind != ObjectKind {
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("cfg: cannot marshal key %q, it is not an object", o.prefix)
+	}
+	if !o.raw {
+		if err := c.expand(entry, o.prefix); err != nil {
+			c.mutex.Unlock()
+			return nil, err
+		}
+	}
+	config, _ := c.marshalValue(o.prefix, entry, o).(map[string]any)
+	c.mutex.Unlock()
+
+	return marshal(config)
+}
+
+// marshalValue converts the entry to a plain value, applying the options
+func (c *Env) marshalValue(key string, e *Entry, o *marshalOptions) any {
+	// the raw value of a whole reference is its expression (Ex. "${db}"), even after the expansion
+	raw := o.raw && e.expr != ""
+	switch {
+	case raw:
+	case e.kind == ArrayKind:
+		list, _ := e.value.([]*Entry)
+		out := make([]any, len(list))
+		for i, entry := range list {
+			out[i] = c.marshalValue(key+"["+strconv.Itoa(i)+"]", entry, o)
+		}
+		return out
+	case e.kind == ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		out := make(map[string]any, len(value))
+		for k, entry := range value {
+			out[k] = c.marshalValue(joinKey(key, Escape(k)), entry, o)
+		}
+		return out
+	}
+
+	if o.redacted {
+		masked := c.IsSensitive(key)
+		for _, pattern := range o.mask {
+			masked = masked || MatchKey(pattern, key)
+		}
+		if masked {
+			return MaskedValue
+		}
+	}
+	if o.raw {
+		return e.rawValue()
+	}
+	return e.value
+}
+
+// JsonMarshal serializes the config as indented JSON
+func JsonMarshal(config map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// YamlMarshal serializes the config as YAML
+func YamlMarshal(config map[string]any) ([]byte, error) {
+	return yaml.Marshal(config)
+}
+
+// sortedKeys keys of the map, sorted
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
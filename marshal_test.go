@@ -0,0 +1,145 @@
+package cfg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnv_Marshal(t *testing.T) {
+	env := New(O{
+		"app": O{"name": "My App", "title": "${app.name}!", "port": 8080, "ratio": 0.5, "debug": true},
+		"db":  O{"host": "localhost", "password": "p4ssw0rd", "tags": []string{"a", "b"}},
+	})
+	env.MarkSensitive("**.password")
+
+	tests := []struct {
+		name   string
+		format string
+		opts   []MarshalOption
+		want   string
+	}{
+		{"json", "json", []MarshalOption{MarshalPrefix("app")}, `{
+  "debug": true,
+  "name": "My App",
+  "port": 8080,
+  "ratio": 0.5,
+  "title": "My App!"
+}
+`},
+		{"yaml raw", "yaml", []MarshalOption{MarshalPrefix("app"), MarshalRaw()}, `debug: true
+name: My App
+port: 8080
+ratio: 0.5
+title: ${app.name}!
+`},
+		{"toml redacted", "toml", []MarshalOption{MarshalRedacted("db.host")}, `[app]
+debug = true
+name = "My App"
+port = 8080
+ratio = 0.5
+title = "My App!"
+
+[db]
+host = "******"
+password = "******"
+tags = ["a", "b"]
+`},
+		{"json secret", "json", []MarshalOption{MarshalPrefix("db")}, `{
+  "host": "localhost",
+  "password": "p4ssw0rd",
+  "tags": [
+    "a",
+    "b"
+  ]
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := env.Marshal(tt.format, tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := env.Marshal("xml"); err == nil {
+		t.Errorf("Marshal() unknown format must fail")
+	}
+	if _, err := env.Marshal("json", MarshalPrefix("app.name")); err == nil {
+		t.Errorf("Marshal() prefix of a value must fail")
+	}
+	if _, err := env.Marshal("json", MarshalPrefix("unknown")); err == nil {
+		t.Errorf("Marshal() unknown prefix must fail")
+	}
+
+	// the raw value of a whole reference does not depend on previous reads
+	env.Set("backup", "${db}")
+	for i := 0; i < 2; i++ {
+		got, err := env.Marshal("json", MarshalRaw(), MarshalRedacted())
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if !strings.Contains(string(got), `"backup": "${db}"`) {
+			t.Errorf("Marshal() = %s, want the expression of backup", got)
+		}
+		env.String("backup.host")
+	}
+
+	env.SetMarshalFn("properties", func(config map[string]any) ([]byte, error) {
+		return []byte("custom"), nil
+	})
+	if got, _ := env.Marshal("properties"); string(got) != "custom" {
+		t.Errorf("Marshal() = %s, want custom", got)
+	}
+}
+
+func TestTomlMarshal(t *testing.T) {
+	config := map[string]any{
+		"title":   "Line 1\n\"quoted\"",
+		"float":   1e21,
+		"servers": []any{map[string]any{"name": "a", "tls": map[string]any{"enabled": true}}, map[string]any{"name": "b"}},
+		"mixed":   []any{int64(1), map[string]any{"a": "b"}},
+		"site":    map[string]any{"google.com": map[string]any{}},
+	}
+
+	got, err := TomlMarshal(config)
+	if err != nil {
+		t.Fatalf("TomlMarshal() error = %v", err)
+	}
+	want := strings.Join([]string{
+		`float = 1e+21`,
+		`mixed = [1, { a = "b" }]`,
+		`title = "Line 1\n\"quoted\""`,
+		``,
+		`[[servers]]`,
+		`name = "a"`,
+		``,
+		`[servers.tls]`,
+		`enabled = true`,
+		``,
+		`[[servers]]`,
+		`name = "b"`,
+		``,
+		`[site]`,
+		``,
+		`[site."google.com"]`,
+		``,
+	}, "\n")
+	if string(got) != want {
+		t.Errorf("TomlMarshal() = \n%s\nwant\n%s", got, want)
+	}
+
+	// round trip
+	parsed, err := TomlUnmarshal(got)
+	if err != nil {
+		t.Fatalf("TomlUnmarshal() error = %v", err)
+	}
+	if g, w := ParseEntry(parsed).Value(), ParseEntry(config).Value(); !reflect.DeepEqual(g, w) {
+		t.Errorf("TomlUnmarshal(TomlMarshal()) = %v, want %v", g, w)
+	}
+}
@@ -0,0 +1,202 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TomlMarshal serializes the config as TOML. Objects are written as tables, arrays of objects as
+// arrays of tables and whole numbers as integers.
+func TomlMarshal(config map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tomlWriteTable(&buf, nil, config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tomlWriteTable writes the values of the table followed by its sub tables
+func tomlWriteTable(buf *bytes.Buffer, path []string, table map[string]any) error {
+	keys := sortedKeys(table)
+
+	for _, k := range keys {
+		v := table[k]
+		if v == nil || tomlIsTable(v) || tomlIsTableArray(v) {
+			continue
+		}
+		value, err := tomlValue(v)
+		if err != nil {
+			return fmt.Errorf("cfg: toml: key %q: %w", strings.Join(append(path, k), "."), err)
+		}
+		buf.WriteString(tomlKey(k) + " = " + value + "\n")
+	}
+
+	for _, k := range keys {
+		child := append(append([]string{}, path...), k)
+		switch v := table[k].(type) {
+		case map[string]any:
+			tomlWriteHeader(buf, "["+tomlPath(child)+"]")
+			if err := tomlWriteTable(buf, child, v); err != nil {
+				return err
+			}
+		case []any:
+			if !tomlIsTableArray(v) {
+				continue
+			}
+			for _, item := range v {
+				tomlWriteHeader(buf, "[["+tomlPath(child)+"]]")
+				if err := tomlWriteTable(buf, child, item.(map[string]any)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func tomlWriteHeader(buf *bytes.Buffer, header string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(header + "\n")
+}
+
+func tomlIsTable(v any) bool {
+	_, isTable := v.(map[string]any)
+	return isTable
+}
+
+// tomlIsTableArray checks if v is a non empty array of tables
+func tomlIsTableArray(v any) bool {
+	list, isList := v.([]any)
+	if !isList || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if !tomlIsTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlKey quotes the key when it is not a bare key
+func tomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if !(c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// tomlString formats a basic string
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// tomlValue formats an inline value
+func tomlValue(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return tomlString(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case float64:
+		return tomlFloat(t), nil
+	case float32:
+		return tomlFloat(float64(t)), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(t), nil
+	case []any:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			if item == nil {
+				continue
+			}
+			value, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, value)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]any:
+		items := make([]string, 0, len(t))
+		for _, k := range sortedKeys(t) {
+			if t[k] == nil {
+				continue
+			}
+			value, err := tomlValue(t[k])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, tomlKey(k)+" = "+value)
+		}
+		if len(items) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
+
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f == math.Trunc(f) && math.Abs(f) < 1e15:
+		return strconv.FormatInt(int64(f), 10)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
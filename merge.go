@@ -0,0 +1,248 @@
+package cfg
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// MergeStrategy defines how an array is merged into the existing array of the same key
+type MergeStrategy string
+
+const (
+	MergeReplace MergeStrategy = "replace" // the array replaces the existing one (default)
+	MergeAppend  MergeStrategy = "append"  // the elements are added after the existing ones
+	MergePrepend MergeStrategy = "prepend" // the elements are added before the existing ones
+	MergeByIndex MergeStrategy = "index"   // each element is merged into the existing element at the same index
+)
+
+// MergeDirective is the property of a config file (at the root) that defines the merge strategies of its
+// arrays, by key pattern (Ex. {"$merge": {"cors.origins": "append", "servers": "key:name"}}) or for all
+// arrays (Ex. {"$merge": "append"})
+const MergeDirective = "$merge"
+
+// MergeByKey objects are merged into the existing object with the same value of the field (Ex. "name"),
+// the other elements are appended
+func MergeByKey(field string) MergeStrategy {
+	return MergeStrategy("key:" + field)
+}
+
+// field of the MergeByKey strategy
+func (s MergeStrategy) field() string {
+	return strings.TrimPrefix(string(s), "key:")
+}
+
+func (s MergeStrategy) validate() error {
+	switch {
+	case s == MergeReplace, s == MergeAppend, s == MergePrepend, s == MergeByIndex:
+		return nil
+	case strings.HasPrefix(string(s), "key:") && s.field() != "":
+		return nil
+	default:
+		return fmt.Errorf("cfg: unknown merge strategy %q", s)
+	}
+}
+
+// mergeRule the strategy of the arrays whose keys match the pattern
+type mergeRule struct {
+	pattern  string
+	strategy MergeStrategy
+}
+
+// SetMergeStrategy defines how the arrays whose keys match the glob pattern (see MatchKey) are merged when
+// loading the config (files, profiles, LoadObject, Set, Merge). The last matching pattern wins, use "**" to
+// change the default for all arrays.
+//
+// Ex. SetMergeStrategy("cors.origins", MergeAppend), SetMergeStrategy("servers", MergeByKey("name"))
+func (c *Env) SetMergeStrategy(pattern string, strategy MergeStrategy) {
+	if err := strategy.validate(); err != nil {
+		slog.Warn("[cfg] ignoring merge strategy.", slog.String("pattern", pattern), slog.Any("error", err))
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.mergeRules = append(c.mergeRules, mergeRule{pattern: pattern, strategy: strategy})
+}
+
+// merger merges the entries using the strategies of the Env, rules (optional) are the directives of the
+// source (see MergeDirective) and take precedence
+func (c *Env) merger(rules ...mergeRule) *merger {
+	return &merger{fold: c.caseInsensitive, rules: append(append([]mergeRule{}, c.mergeRules...), rules...)}
+}
+
+// mergeDirective removes the MergeDirective from the config, returns its rules. Invalid rules are ignored.
+func mergeDirective(config O) (O, []mergeRule) {
+	directive, exist := config[MergeDirective]
+	if !exist {
+		return config, nil
+	}
+
+	out := O{}
+	for key, value := range config {
+		if key != MergeDirective {
+			out[key] = value
+		}
+	}
+
+	if strategy, isString := directive.(string); isString {
+		directive = map[string]any{"**": strategy}
+	}
+
+	var rules []mergeRule
+	patterns, _ := directive.(map[string]any)
+	for _, pattern := range sortedKeys(patterns) {
+		strategy := MergeStrategy(fmt.Sprint(patterns[pattern]))
+		if err := strategy.validate(); err != nil {
+			slog.Warn("[cfg] ignoring merge directive.", slog.String("key", pattern), slog.Any("error", err))
+			continue
+		}
+		rules = append(rules, mergeRule{pattern: pattern, strategy: strategy})
+	}
+	return out, rules
+}
+
+// merger merges the entries, see Entry.Merge
+type merger struct {
+	fold  bool        // matches the keys ignoring case (keeping the casing of the existing keys)
+	rules []mergeRule // array merge strategies, the last match wins
+}
+
+// strategy the merge strategy of the array with the key
+func (m *merger) strategy(key string) MergeStrategy {
+	if m.fold {
+		key = strings.ToLower(key)
+	}
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		pattern := m.rules[i].pattern
+		if m.fold {
+			pattern = strings.ToLower(pattern)
+		}
+		if MatchKey(pattern, key) {
+			return m.rules[i].strategy
+		}
+	}
+	return MergeReplace
+}
+
+// merge merges other into the entry with the key. A null property (NullKind) removes the existing one.
+func (m *merger) merge(e, other *Entry, key string) {
+	switch other.kind {
+	case BoolKind, StringKind, NumberKind, ArrayKind:
+		if other.kind == ArrayKind && e.kind == ArrayKind {
+			e.value = m.mergeArray(e, other.dropNulls(), key)
+		} else {
+			e.value = other.dropNulls().value
+		}
+		e.expr = other.expr
+		e.origins = mergeOrigins(other.origins, e.origins)
+	case ObjectKind:
+		if e.value == nil || e.kind != ObjectKind {
+			e.value = map[string]*Entry{}
+		}
+		e.kind = ObjectKind
+		e.expr = ""
+		if len(other.origins) > 0 {
+			// only the last origin, the overridden values are in the properties
+			e.origins = other.origins
+		}
+
+		target := e.value.(map[string]*Entry)
+		source, _ := other.value.(map[string]*Entry)
+		for name, src := range source {
+			name = lookupKey(target, name, m.fold)
+			if src.kind == NullKind {
+				delete(target, name)
+			} else if dest, exist := target[name]; !exist || dest == nil {
+				target[name] = src.dropNulls()
+			} else if src.kind != dest.kind {
+				src.origins = mergeOrigins(src.origins, dest.origins)
+				target[name] = src.dropNulls()
+			} else {
+				m.merge(dest, src, joinKey(key, Escape(name)))
+			}
+		}
+	}
+}
+
+// mergeEntry merges src into dest, same rules as merge for object properties. Returns nil when src is
+// null (NullKind).
+func (m *merger) mergeEntry(dest, src *Entry, key string) *Entry {
+	if src.kind == NullKind {
+		return nil
+	} else if dest == nil {
+		return src.dropNulls()
+	} else if src.kind != dest.kind {
+		src.origins = mergeOrigins(src.origins, dest.origins)
+		return src.dropNulls()
+	}
+	m.merge(dest, src, key)
+	return dest
+}
+
+// mergeArray the elements of the array with the key after merging other, see MergeStrategy
+func (m *merger) mergeArray(e, other *Entry, key string) []*Entry {
+	list, _ := e.value.([]*Entry)
+	elements, _ := other.value.([]*Entry)
+
+	strategy := m.strategy(key)
+	switch {
+	case strategy == MergeAppend:
+		return append(append([]*Entry{}, list...), elements...)
+	case strategy == MergePrepend:
+		return append(append([]*Entry{}, elements...), list...)
+	case strategy == MergeByIndex:
+		value := append([]*Entry{}, list...)
+		for i, src := range elements {
+			if i < len(value) {
+				value[i] = m.mergeEntry(value[i], src, key+"["+strconv.Itoa(i)+"]")
+			} else {
+				value = append(value, src)
+			}
+		}
+		return value
+	case strings.HasPrefix(string(strategy), "key:"):
+		field := strategy.field()
+		value := append([]*Entry{}, list...)
+		for _, src := range elements {
+			if i := m.indexOf(value, src, field); i >= 0 {
+				value[i] = m.mergeEntry(value[i], src, key+"["+strconv.Itoa(i)+"]")
+			} else {
+				value = append(value, src)
+			}
+		}
+		return value
+	default:
+		return elements
+	}
+}
+
+// indexOf the index of the object of the list with the same value of the field as src, -1 if not found
+func (m *merger) indexOf(list []*Entry, src *Entry, field string) int {
+	id := fieldOf(src, field, m.fold)
+	if id == nil {
+		return -1
+	}
+	for i, entry := range list {
+		if other := fieldOf(entry, field, m.fold); other != nil && other.kind == id.kind && other.value == id.value {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldOf the scalar property of the object, nil if the entry is not an object or the property does not exist
+func fieldOf(e *Entry, field string, fold bool) *Entry {
+	obj, isObject := e.value.(map[string]*Entry)
+	if e.kind != ObjectKind || !isObject {
+		return nil
+	}
+	switch child := obj[lookupKey(obj, field, fold)]; {
+	case child == nil:
+		return nil
+	case child.kind == BoolKind, child.kind == StringKind, child.kind == NumberKind:
+		return child
+	default:
+		return nil
+	}
+}
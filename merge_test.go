@@ -0,0 +1,137 @@
+package cfg
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEnv_SetMergeStrategy(t *testing.T) {
+	base := O{
+		"origins": []any{"a", "b"},
+		"servers": []any{
+			O{"name": "api", "port": 80},
+			O{"name": "web", "port": 81},
+		},
+	}
+	override := O{
+		"origins": []any{"c"},
+		"servers": []any{
+			O{"name": "web", "port": 8081},
+			O{"name": "admin", "port": 82},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		origins  []any
+		servers  []any
+	}{
+		{
+			"replace", MergeReplace,
+			[]any{"c"},
+			[]any{
+				map[string]any{"name": "web", "port": float64(8081)},
+				map[string]any{"name": "admin", "port": float64(82)},
+			},
+		},
+		{
+			"append", MergeAppend,
+			[]any{"a", "b", "c"},
+			[]any{
+				map[string]any{"name": "api", "port": float64(80)},
+				map[string]any{"name": "web", "port": float64(81)},
+				map[string]any{"name": "web", "port": float64(8081)},
+				map[string]any{"name": "admin", "port": float64(82)},
+			},
+		},
+		{
+			"prepend", MergePrepend,
+			[]any{"c", "a", "b"},
+			[]any{
+				map[string]any{"name": "web", "port": float64(8081)},
+				map[string]any{"name": "admin", "port": float64(82)},
+				map[string]any{"name": "api", "port": float64(80)},
+				map[string]any{"name": "web", "port": float64(81)},
+			},
+		},
+		{
+			"index", MergeByIndex,
+			[]any{"c", "b"},
+			[]any{
+				map[string]any{"name": "web", "port": float64(8081)},
+				map[string]any{"name": "admin", "port": float64(82)},
+			},
+		},
+		{
+			"key", MergeByKey("name"),
+			[]any{"a", "b", "c"},
+			[]any{
+				map[string]any{"name": "api", "port": float64(80)},
+				map[string]any{"name": "web", "port": float64(8081)},
+				map[string]any{"name": "admin", "port": float64(82)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := New(base)
+			env.SetMergeStrategy("**", tt.strategy)
+			env.LoadObject(override)
+
+			if got := env.Get("origins"); !reflect.DeepEqual(got, tt.origins) {
+				t.Errorf("Get(origins) = %v, want %v", got, tt.origins)
+			}
+			if got := env.Get("servers"); !reflect.DeepEqual(got, tt.servers) {
+				t.Errorf("Get(servers) = %v, want %v", got, tt.servers)
+			}
+		})
+	}
+}
+
+func TestEnv_SetMergeStrategyPattern(t *testing.T) {
+	env := New(O{"cors": O{"origins": []any{"a"}, "methods": []any{"GET"}}})
+	env.SetMergeStrategy("cors.origins", MergeAppend)
+	env.SetMergeStrategy("cors.origins", MergeStrategy("invalid")) // ignored
+
+	env.LoadObject(O{"cors": O{"origins": []any{"b"}, "methods": []any{"POST"}}})
+	env.Set("cors.origins", []string{"c"})
+
+	if got := env.Strings("cors.origins"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Strings() = %v, want [a b c]", got)
+	}
+	if got := env.Strings("cors.methods"); !reflect.DeepEqual(got, []string{"POST"}) {
+		t.Errorf("Strings() = %v, want [POST]", got)
+	}
+}
+
+func TestEnv_MergeDirective(t *testing.T) {
+	env := New()
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.yaml": {Data: []byte("origins: [a]\nservers:\n  - name: api\n    port: 80\n")},
+		"config-dev.yaml": {Data: []byte(
+			"$merge:\n  origins: append\n  servers: key:name\n" +
+				"origins: [b]\nservers:\n  - name: api\n    port: 8080\n",
+		)},
+		"config-test.json": {Data: []byte(`{"$merge": "prepend", "origins": ["c"]}`)},
+	}))
+	env.Set("profiles", "dev,test")
+	if err := env.LoadFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.LoadProfiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := env.Strings("origins"); !reflect.DeepEqual(got, []string{"c", "a", "b"}) {
+		t.Errorf("Strings() = %v, want [c a b]", got)
+	}
+	if got, want := env.Get("servers"), []any{map[string]any{"name": "api", "port": float64(8080)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+	if got := env.Get(MergeDirective); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+}
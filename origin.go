@@ -0,0 +1,223 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin identifies the source that defined a config value
+type Origin struct {
+	Source string `json:"source"`           // SourceDefault, SourceFile, SourceDotEnv, SourceEnv, SourceArgs, ...
+	Path   string `json:"path,omitempty"`   // file path, when loaded from a file
+	Line   int    `json:"line,omitempty"`   // line in the file, 0 when the parser cannot supply it
+	Column int    `json:"column,omitempty"` // column in the file, 0 when the parser cannot supply it
+	Order  int    `json:"order"`            // load order, later sources take precedence
+}
+
+// String formats the origin (Ex. "file config.yaml:2:3")
+func (o Origin) String() string {
+	out := o.Source
+	if o.Path != "" {
+		out += " " + o.Path
+		if o.Line > 0 {
+			out += ":" + strconv.Itoa(o.Line) + ":" + strconv.Itoa(o.Column)
+		}
+	}
+	return out
+}
+
+// Origin returns the sources of the key, the first one is the effective (winning)
+// source followed by the overridden ones.
+func (c *Env) Origin(key string) []Origin {
+	unlock := c.lock(false)
+	defer unlock()
+
+	entry := c.getEntryUnsafe(key)
+	if entry == nil {
+		return nil
+	}
+	return append([]Origin{}, entry.origins...)
+}
+
+// position line and column of a key in a file
+type position struct {
+	line   int
+	column int
+}
+
+// positionParsers extracts the position of the keys, by file extension
+var positionParsers = map[string]func(content []byte) map[string]position{
+	"json":  jsonPositions,
+	"jsonc": json5Positions,
+	"json5": json5Positions,
+	"yml":   yamlPositions,
+	"yaml":  yamlPositions,
+}
+
+// stamp defines the origin of the entry and its children
+func (e *Entry) stamp(key string, origin Origin, positions map[string]position) {
+	o := origin
+	if p, exist := positions[key]; exist {
+		o.Line, o.Column = p.line, p.column
+	}
+	e.origins = []Origin{o}
+
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		for i, entry := range list {
+			entry.stamp(key+"["+strconv.Itoa(i)+"]", origin, positions)
+		}
+	case ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		for k, entry := range value {
+			entry.stamp(joinKey(key, Escape(k)), origin, positions)
+		}
+	}
+}
+
+// shiftOrder adds offset to the load order of the entry and its children
+func (e *Entry) shiftOrder(offset int) {
+	for i := range e.origins {
+		e.origins[i].Order += offset
+	}
+	switch e.kind {
+	case ArrayKind:
+		list, _ := e.value.([]*Entry)
+		for _, entry := range list {
+			entry.shiftOrder(offset)
+		}
+	case ObjectKind:
+		value, _ := e.value.(map[string]*Entry)
+		for _, entry := range value {
+			entry.shiftOrder(offset)
+		}
+	}
+}
+
+func yamlPositions(content []byte) map[string]position {
+	positions := map[string]position{}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return positions
+	}
+
+	var walk func(key string, node *yaml.Node)
+	walk = func(key string, node *yaml.Node) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				k, v := node.Content[i], node.Content[i+1]
+				child := joinKey(key, Escape(k.Value))
+				positions[child] = position{line: k.Line, column: k.Column}
+				walk(child, v)
+			}
+		case yaml.SequenceNode:
+			for i, v := range node.Content {
+				child := key + "[" + strconv.Itoa(i) + "]"
+				positions[child] = position{line: v.Line, column: v.Column}
+				walk(child, v)
+			}
+		case yaml.AliasNode:
+			if node.Alias != nil {
+				walk(key, node.Alias)
+			}
+		}
+	}
+	walk("", doc.Content[0])
+	return positions
+}
+
+func jsonPositions(content []byte) map[string]position {
+	return jsonTokenPositions(content, content, nil)
+}
+
+func json5Positions(content []byte) map[string]position {
+	data, offsets, err := json5ToJson(content)
+	if err != nil {
+		return map[string]position{}
+	}
+	return jsonTokenPositions(content, data, offsets)
+}
+
+// jsonTokenPositions reads the keys of the JSON data, offsets maps the data to the content (nil when equal)
+func jsonTokenPositions(content, data []byte, offsets []int) map[string]position {
+	positions := map[string]position{}
+
+	// offset of the beginning of each line
+	lines := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	positionOf := func(offset int) position {
+		if offsets != nil && offset < len(offsets) {
+			offset = offsets[offset]
+		}
+		line := sort.Search(len(lines), func(i int) bool { return lines[i] > offset })
+		return position{line: line, column: offset - lines[line-1] + 1}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// next reads the next token and the offset where it begins
+	next := func() (json.Token, int, error) {
+		offset := int(dec.InputOffset())
+		for offset < len(data) && bytes.IndexByte([]byte(" \t\r\n,:"), data[offset]) >= 0 {
+			offset++
+		}
+		tok, err := dec.Token()
+		return tok, offset, err
+	}
+
+	var walk func(key string, tok json.Token) error
+	walk = func(key string, tok json.Token) error {
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				k, offset, err := next()
+				if err != nil {
+					return err
+				}
+				child := joinKey(key, Escape(k.(string)))
+				positions[child] = positionOf(offset)
+
+				v, _, err := next()
+				if err != nil {
+					return err
+				}
+				if err = walk(child, v); err != nil {
+					return err
+				}
+			}
+			_, err := dec.Token() // }
+			return err
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				v, offset, err := next()
+				if err != nil {
+					return err
+				}
+				child := key + "[" + strconv.Itoa(i) + "]"
+				positions[child] = positionOf(offset)
+				if err = walk(child, v); err != nil {
+					return err
+				}
+			}
+			_, err := dec.Token() // ]
+			return err
+		}
+		return nil
+	}
+
+	if tok, _, err := next(); err == nil {
+		_ = walk("", tok)
+	}
+	return positions
+}
@@ -0,0 +1,79 @@
+package cfg
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEnv_Origin(t *testing.T) {
+	env := New(O{"server": O{"port": 80, "host": "localhost"}})
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.yaml":     {Data: []byte("server:\n  port: 8080\n  tls:\n    - a\n    - b\n")},
+		"config-dev.json": {Data: []byte("{\n  \"server\": {\n    \"port\": 9090\n  }\n}")},
+	}))
+	env.SetFileExt("yml", nil)
+	env.SetFileExt("json", nil)
+
+	if err := env.LoadFiles(); err != nil {
+		t.Fatal(err)
+	}
+	env.SetFileExt("json", JsonUnmarshal)
+	env.Set("profiles", "dev")
+	if err := env.LoadProfiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := New()
+	h.LoadOsArgs([]string{"--server.port=7070"})
+	env.Merge(h)
+
+	want := []Origin{
+		{Source: SourceArgs, Order: 5},
+		{Source: SourceFile, Path: "config-dev.json", Line: 3, Column: 5, Order: 4},
+		{Source: SourceFile, Path: "config.yaml", Line: 2, Column: 3, Order: 2},
+		{Source: SourceDefault, Order: 1},
+	}
+	if got := env.Origin("server.port"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %+v, want %+v", got, want)
+	}
+
+	want = []Origin{{Source: SourceFile, Path: "config.yaml", Line: 5, Column: 7, Order: 2}}
+	if got := env.Origin("server.tls[1]"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %+v, want %+v", got, want)
+	}
+
+	want = []Origin{{Source: SourceDefault, Order: 1}}
+	if got := env.Origin("server.host"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %+v, want %+v", got, want)
+	}
+
+	if got := env.Origin("missing"); got != nil {
+		t.Errorf("Origin() = %+v, want nil", got)
+	}
+}
+
+func TestEnv_Origin_History(t *testing.T) {
+	env := New(O{"a": O{"b": 0}})
+	env.SetFileSystem(http.FS(fstest.MapFS{"config.json": {Data: []byte(`{"a": {"b": 1}}`)}}))
+	if err := env.LoadFiles(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		env.Set("a.b", i)
+		env.LoadObject(O{"a": O{"c": i}})
+	}
+
+	want := []Origin{
+		{Source: SourceSet, Order: 2001},
+		{Source: SourceFile, Path: "config.json", Line: 1, Column: 8, Order: 2},
+		{Source: SourceDefault, Order: 1},
+	}
+	if got := env.Origin("a.b"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Origin() = %+v, want %+v", got, want)
+	}
+	if got := env.Origin("a"); len(got) != 1 || got[0].Source != SourceObject {
+		t.Errorf("Origin() = %+v, want the last object", got)
+	}
+}
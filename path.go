@@ -0,0 +1,183 @@
+package cfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment a segment of a key path, an object key or an array index (Ex. "servers[0].host")
+type pathSegment struct {
+	key     string // object key
+	index   int    // array index, when isIndex
+	isIndex bool
+	appends bool // "[+]" or "[-1]", a new element at the end of the array
+}
+
+// parsePath parses the key into object keys and array indexes ("a.b[0][1].c" => a, b, 0, 1, c). The index
+// "[+]" (or "[-1]") appends a new element to the array.
+func parsePath(key string) ([]pathSegment, error) {
+	var path []pathSegment
+	for _, segment := range Segments(key) {
+		name := segment
+		indexes := ""
+		if i := strings.IndexByte(segment, '['); i >= 0 {
+			name, indexes = segment[:i], segment[i:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("cfg: invalid key %q, empty segment", key)
+		}
+		path = append(path, pathSegment{key: name})
+
+		for indexes != "" {
+			end := strings.IndexByte(indexes, ']')
+			if indexes[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("cfg: invalid key %q, expects \"name[0]\"", key)
+			}
+			if index := indexes[1:end]; index == "+" || index == "-1" {
+				path = append(path, pathSegment{isIndex: true, appends: true})
+				indexes = indexes[end+1:]
+				continue
+			}
+			index, err := strconv.Atoi(indexes[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("cfg: invalid key %q, invalid index %q", key, indexes[1:end])
+			}
+			path = append(path, pathSegment{index: index, isIndex: true})
+			indexes = indexes[end+1:]
+		}
+	}
+	return path, nil
+}
+
+// setPath merges the value at the path, creating the missing objects and arrays (with the origin). The
+// index of an array must exist or be equal to its length (appends a new element, same as "[+]").
+func (e *Entry) setPath(path []pathSegment, value *Entry, origin Origin, m *merger) error {
+	if value.kind == NullKind {
+		e.deletePath(path, m.fold)
+		return nil
+	}
+	if err := e.checkPath(path, m.fold); err != nil {
+		return err
+	}
+	e.assign(path, "", value, origin, m)
+	return nil
+}
+
+// deletePath removes the property or the array element (the following elements are shifted) at the path,
+// if it exists
+func (e *Entry) deletePath(path []pathSegment, fold bool) {
+	for _, segment := range path[:len(path)-1] {
+		if e = e.at(segment, fold); e == nil {
+			return
+		}
+	}
+
+	segment := path[len(path)-1]
+	if segment.isIndex {
+		list, _ := e.value.([]*Entry)
+		if e.kind == ArrayKind && !segment.appends && segment.index < len(list) {
+			e.value = append(append([]*Entry{}, list[:segment.index]...), list[segment.index+1:]...)
+		}
+	} else if obj, isObject := e.value.(map[string]*Entry); e.kind == ObjectKind && isObject {
+		delete(obj, lookupKey(obj, segment.key, fold))
+	}
+}
+
+// at the child of the entry at the segment, nil if it does not exist
+func (e *Entry) at(segment pathSegment, fold bool) *Entry {
+	if e == nil {
+		return nil
+	} else if segment.isIndex {
+		list, _ := e.value.([]*Entry)
+		if e.kind != ArrayKind || segment.appends || segment.index >= len(list) {
+			return nil
+		}
+		return list[segment.index]
+	} else if obj, isObject := e.value.(map[string]*Entry); e.kind == ObjectKind && isObject {
+		return obj[lookupKey(obj, segment.key, fold)]
+	}
+	return nil
+}
+
+// checkPath validates the array indexes of the path, so an invalid path does not change the tree
+func (e *Entry) checkPath(path []pathSegment, fold bool) error {
+	for _, segment := range path {
+		var next *Entry
+		if segment.isIndex {
+			var list []*Entry
+			if e != nil && e.kind == ArrayKind {
+				list, _ = e.value.([]*Entry)
+			}
+			if !segment.appends && segment.index > len(list) {
+				return fmt.Errorf("cfg: index %d out of range, the array has %d elements", segment.index, len(list))
+			} else if !segment.appends && segment.index < len(list) {
+				next = list[segment.index]
+			}
+		} else if e != nil && e.kind == ObjectKind {
+			obj, _ := e.value.(map[string]*Entry)
+			next = obj[lookupKey(obj, segment.key, fold)]
+		}
+		e = next
+	}
+	return nil
+}
+
+// assign see setPath, the path must be valid (checkPath). The key of the entry is used by the merge strategies.
+func (e *Entry) assign(path []pathSegment, key string, value *Entry, origin Origin, m *merger) {
+	segment := path[0]
+
+	var child *Entry
+	if segment.isIndex {
+		if e.kind != ArrayKind {
+			e.convert(ArrayKind, origin)
+		}
+		list, _ := e.value.([]*Entry)
+		if segment.appends {
+			segment.index = len(list)
+		}
+		if segment.index == len(list) {
+			list = append(list, nil)
+			e.value = list
+		}
+		child = list[segment.index]
+		key += "[" + strconv.Itoa(segment.index) + "]"
+		if len(path) == 1 {
+			list[segment.index] = m.mergeEntry(child, value, key)
+			return
+		} else if child == nil {
+			child = &Entry{kind: ObjectKind, origins: []Origin{origin}}
+			list[segment.index] = child
+		}
+	} else {
+		if e.kind != ObjectKind || e.value == nil {
+			e.convert(ObjectKind, origin)
+		}
+		obj := e.value.(map[string]*Entry)
+		name := lookupKey(obj, segment.key, m.fold)
+		child = obj[name]
+		key = joinKey(key, Escape(name))
+		if len(path) == 1 {
+			obj[name] = m.mergeEntry(child, value, key)
+			return
+		} else if child == nil {
+			child = &Entry{kind: ObjectKind, origins: []Origin{origin}}
+			obj[name] = child
+		}
+	}
+	child.assign(path[1:], key, value, origin, m)
+}
+
+// convert changes the entry to an empty array or object, keeping the overridden origins
+func (e *Entry) convert(kind EntryKind, origin Origin) {
+	if e.kind != kind {
+		e.origins = mergeOrigins([]Origin{origin}, e.origins)
+	}
+	e.kind = kind
+	e.expr = ""
+	if kind == ArrayKind {
+		e.value = []*Entry(nil)
+	} else if obj, isObject := e.value.(map[string]*Entry); !isObject || obj == nil {
+		e.value = map[string]*Entry{}
+	}
+}
@@ -0,0 +1,68 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    []pathSegment
+		wantErr bool
+	}{
+		{"a", []pathSegment{{key: "a"}}, false},
+		{"a.b", []pathSegment{{key: "a"}, {key: "b"}}, false},
+		{`a\.b.c`, []pathSegment{{key: "a.b"}, {key: "c"}}, false},
+		{"a[0].b", []pathSegment{{key: "a"}, {index: 0, isIndex: true}, {key: "b"}}, false},
+		{"a[1][2]", []pathSegment{{key: "a"}, {index: 1, isIndex: true}, {index: 2, isIndex: true}}, false},
+		{"a[x]", nil, true},
+		{"a[+]", []pathSegment{{key: "a"}, {isIndex: true, appends: true}}, false},
+		{"a[-1].b", []pathSegment{{key: "a"}, {isIndex: true, appends: true}, {key: "b"}}, false},
+		{"a[-2]", nil, true},
+		{"a[0", nil, true},
+		{"a[0]b", nil, true},
+		{"a..b", nil, true},
+		{"[0]", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := parsePath(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry_setPath(t *testing.T) {
+	root := ParseEntry(O{"a": "text", "list": []any{O{"x": 1}}})
+
+	set := func(key string, value any) error {
+		path, err := parsePath(key)
+		if err != nil {
+			return err
+		}
+		return root.setPath(path, ParseEntry(value), Origin{Source: SourceSet}, &merger{})
+	}
+	for _, kv := range [][2]any{{"a.b", 1}, {"list[0].y", 2}, {"list[1]", "new"}, {"m[0][0]", true}, {"m[0][+]", false}} {
+		if err := set(kv[0].(string), kv[1]); err != nil {
+			t.Fatalf("setPath(%q) error = %v", kv[0], err)
+		}
+	}
+	if err := set("list[5]", 1); err == nil {
+		t.Errorf("setPath() out of range must fail")
+	}
+
+	want := map[string]any{
+		"a":    map[string]any{"b": float64(1)},
+		"list": []any{map[string]any{"x": float64(1), "y": float64(2)}, "new"},
+		"m":    []any{[]any{true, false}},
+	}
+	if got := root.Value(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ResolverFn resolves the argument of a reference with its scheme (Ex. "${env:HOME}" => fn("HOME")). Returns
+// ErrKeyNotFound when the value does not exist, so the operators apply (Ex. "${env:PORT:-8080}").
+type ResolverFn func(arg string) (string, error)
+
+// RegisterResolver registers the resolver of the references with the scheme (Ex. "vault" for
+// "${vault:secret/db}"), nil removes the resolver. Built-in: env, file, base64, hex and sys.
+func (c *Env) RegisterResolver(scheme string, fn ResolverFn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if fn == nil {
+		delete(c.resolvers, scheme)
+	} else {
+		c.resolvers[scheme] = fn
+	}
+	if scheme == "file" {
+		c.fileCustom = true
+	}
+	c.cache = map[string]*cacheEntry{}
+	c.resetExpressions()
+}
+
+// copyResolvers copies the resolvers to o, the built-in "file" resolver reads the FileSystem of o
+func (c *Env) copyResolvers(o *Env) {
+	o.resolvers = map[string]ResolverFn{}
+	for scheme, fn := range c.resolvers {
+		o.resolvers[scheme] = fn
+	}
+	if o.fileCustom = c.fileCustom; !c.fileCustom {
+		o.resolvers["file"] = o.resolveFile
+	}
+}
+
+// reference the value of the key referenced by an expression. "${profile}" is the list of active profiles
+// (comma separated), unless a "profile" key exists.
+func (c *Env) reference(key string) (string, bool, error) {
+	value, exist, err := c.getStringUnsafe(key)
+	if !exist && err == nil && key == "profile" {
+		if value, _, err = c.getStringUnsafe(c.profileKey); err == nil {
+			profiles := profileList(value)
+			return strings.Join(profiles, ","), len(profiles) > 0, nil
+		}
+	}
+	return value, exist, err
+}
+
+// resolveFile the content of the file (Ex. "${file:/run/secrets/db_password}"), read through the FileSystem
+// of the Env (see SetFileSystem), without leading and trailing spaces. With the default FileSystem, absolute
+// paths are read from the root of the OS file system. A file that does not exist is an error, unless an operator
+// applies (Ex. "${file:/run/secrets/db_password:-changeme}").
+func (c *Env) resolveFile(path string) (string, error) {
+	var content []byte
+	var err error
+	if filepath.IsAbs(path) && c.fsDefault {
+		if content, err = os.ReadFile(path); errors.Is(err, os.ErrNotExist) {
+			content, err = nil, nil
+		}
+	} else {
+		// the lock is held by the expansion
+		content, err = readFile(c.fs, path)
+	}
+	if err != nil {
+		return "", err
+	} else if content == nil {
+		return "", &missingError{err: fmt.Errorf("%w: file %q", ErrKeyNotFound, path)}
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// missingError a value that does not exist and is required: the operators apply, otherwise the reference
+// is an error (unlike ErrKeyNotFound, which is an empty value)
+type missingError struct {
+	err error
+}
+
+func (e *missingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *missingError) Unwrap() error {
+	return e.err
+}
+
+// EnvResolver the value of the environment variable (Ex. "${env:HOME}")
+func EnvResolver(name string) (string, error) {
+	if value, exist := os.LookupEnv(name); exist {
+		return value, nil
+	}
+	return "", keyNotFound(name)
+}
+
+// Base64Resolver decodes the standard base64 encoded value (Ex. "${base64:aGVsbG8=}")
+func Base64Resolver(value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// HexResolver decodes the hex encoded value (Ex. "${hex:68656c6c6f}")
+func HexResolver(value string) (string, error) {
+	decoded, err := hex.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// SysResolver properties of the system: "hostname", "pid" and "cpus" (Ex. "${sys:hostname}")
+func SysResolver(name string) (string, error) {
+	switch name {
+	case "hostname":
+		return os.Hostname()
+	case "pid":
+		return strconv.Itoa(os.Getpid()), nil
+	case "cpus":
+		return strconv.Itoa(runtime.NumCPU()), nil
+	default:
+		return "", fmt.Errorf("unknown system property %q", name)
+	}
+}
@@ -0,0 +1,152 @@
+package cfg
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEnv_RegisterResolver(t *testing.T) {
+	t.Setenv("CFG_TEST_HOME", "/home/cfg")
+
+	env := New(O{
+		"secrets":  "run/secrets",
+		"profiles": "dev, local",
+		"env":      "${env:CFG_TEST_HOME}",
+		"missing":  "${env:CFG_TEST_MISSING:-default}",
+		"file":     "${file:run/secrets/db-password}",
+		"nested":   "${file:${secrets}/db-password}",
+		"noFile":   "${file:run/secrets/other:-none}",
+		"base64":   "${base64:aGVsbG8gd29ybGQ=}",
+		"hex":      "${hex:68656c6c6f}",
+		"pid":      "${sys:pid}",
+		"cpus":     "${sys:cpus}",
+		"log":      "logs/${profile}.log",
+		"vault":    "${vault:db/pass}",
+		"unknown":  "${other:value:-fallback}",
+	})
+	env.SetFileSystem(http.FS(fstest.MapFS{"run/secrets/db-password": {Data: []byte("s3cr3t\n")}}))
+	env.RegisterResolver("vault", func(arg string) (string, error) {
+		return strings.ToUpper(arg), nil
+	})
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"env", "/home/cfg"},
+		{"missing", "default"},
+		{"file", "s3cr3t"},
+		{"nested", "s3cr3t"},
+		{"noFile", "none"},
+		{"base64", "hello world"},
+		{"hex", "hello"},
+		{"pid", strconv.Itoa(os.Getpid())},
+		{"cpus", strconv.Itoa(runtime.NumCPU())},
+		{"log", "logs/dev,local.log"},
+		{"vault", "DB/PASS"},
+		{"unknown", "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got, err := env.LookupString(tt.key); err != nil || got != tt.want {
+				t.Errorf("LookupString() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	env.Set("hostname", "${sys:hostname}")
+	if want, _ := os.Hostname(); env.String("hostname") != want {
+		t.Errorf("String() = %q, want %q", env.String("hostname"), want)
+	}
+
+	// the argument is not part of the error, it can be a secret
+	env.Set("invalid", "${base64:%%secret%%}")
+	_, err := env.LookupString("invalid")
+	var exprErr *ExpressionError
+	if !errors.As(err, &exprErr) || strings.Contains(err.Error(), "secret") {
+		t.Errorf("LookupString() error = %v, want *ExpressionError without the argument", err)
+	}
+
+	env.RegisterResolver("vault", nil)
+	if got := env.String("vault"); got != "" {
+		t.Errorf("String() = %q, want empty", got)
+	}
+}
+
+func TestEnv_resolveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db-password")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(filepath.Dir(path), "other")
+
+	env := New(O{
+		"absolute": "${file:" + path + "}",
+		"missing":  "${file:" + missing + "}",
+		"default":  "${file:" + missing + ":-none}",
+		"required": "${file:" + missing + ":?must be mounted}",
+	})
+
+	if got, err := env.LookupString("absolute"); err != nil || got != "s3cr3t" {
+		t.Errorf("LookupString() = %q, %v, want s3cr3t", got, err)
+	}
+	if got, err := env.LookupString("default"); err != nil || got != "none" {
+		t.Errorf("LookupString() = %q, %v, want none", got, err)
+	}
+
+	_, err := env.LookupString("missing")
+	var exprErr *ExpressionError
+	if !errors.As(err, &exprErr) || exprErr.Key != "missing" || !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("LookupString() error = %v, want *ExpressionError of a missing file", err)
+	}
+	if _, err := env.LookupString("required"); err == nil || !strings.Contains(err.Error(), "must be mounted") {
+		t.Errorf("LookupString() error = %v, want the message", err)
+	}
+
+	// the FileSystem set is the root of the absolute paths
+	env = New(O{"absolute": "${file:" + path + "}"})
+	env.SetFileSystem(http.FS(fstest.MapFS{}))
+	if _, err := env.LookupString("absolute"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("LookupString() error = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestEnv_Clone_Resolvers(t *testing.T) {
+	env := New(O{"secret": "${file:db}", "custom": "${vault:db}"})
+	env.SetFileSystem(http.FS(fstest.MapFS{"db": {Data: []byte("from-a")}}))
+	env.RegisterResolver("vault", func(arg string) (string, error) {
+		return "vault-" + arg, nil
+	})
+
+	clone := env.Clone()
+	if got := clone.String("secret"); got != "from-a" {
+		t.Errorf("String() = %q, want from-a", got)
+	}
+
+	// the file resolver of the clone reads its own FileSystem
+	clone.SetFileSystem(http.FS(fstest.MapFS{"db": {Data: []byte("from-b")}}))
+	if got := clone.String("secret"); got != "from-b" {
+		t.Errorf("String() = %q, want from-b", got)
+	}
+	if got := env.String("secret"); got != "from-a" {
+		t.Errorf("String() = %q, want from-a", got)
+	}
+	if got := clone.String("custom"); got != "vault-db" {
+		t.Errorf("String() = %q, want vault-db", got)
+	}
+
+	// a custom file resolver is kept
+	env.RegisterResolver("file", func(arg string) (string, error) {
+		return "custom-" + arg, nil
+	})
+	if got := env.Clone().String("secret"); got != "custom-db" {
+		t.Errorf("String() = %q, want custom-db", got)
+	}
+}
@@ -0,0 +1,425 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaDraft identifies the JSON Schema version generated by GenerateSchema
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// ValidateSchema validates the merged config against the JSON Schema document found
+// in filepath (loaded using the Env FileSystem). Returns ValidationErrors listing
+// every violation, or *ExpressionError when the values cannot be expanded.
+//
+// Supported keywords: type, enum, const, properties, required, additionalProperties,
+// items, minItems, maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, allOf, anyOf, oneOf and not. Other keywords are ignored.
+func (c *Env) ValidateSchema(filepath string) error {
+	content, err := c.loadFile(filepath)
+	if err != nil {
+		return err
+	} else if content == nil {
+		return fmt.Errorf("cfg: schema %q: %w", filepath, os.ErrNotExist)
+	}
+
+	c.mutex.RLock()
+	unmarshal, exist := c.fileExts[strings.TrimPrefix(path.Ext(filepath), ".")]
+	c.mutex.RUnlock()
+	if !exist {
+		unmarshal = JsonUnmarshal
+	}
+	schema, err := unmarshal(content)
+	if err != nil {
+		return err
+	}
+
+	// the expressions must be expanded (Ex. "${db.port:?required}")
+	value, _, err := c.getValue("")
+	if err != nil {
+		return err
+	}
+
+	errs := validateSchema("", value, schema)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Schema generates a JSON Schema from the current config, see GenerateSchema.
+func (c *Env) Schema() O {
+	unlock := c.lock(true)
+	defer unlock()
+
+	return withSchemaDraft(entrySchema("", c.root, c.IsSensitive))
+}
+
+// GenerateSchema generates a draft JSON Schema from the defaults object (the same passed
+// to cfg.New), mapping the EntryKind of every key to its JSON type.
+func GenerateSchema(defaults O) O {
+	entry := &Entry{}
+	parseEntryMap(defaults, entry)
+	return withSchemaDraft(entrySchema("", entry, nil))
+}
+
+// GenerateStructSchema generates a draft JSON Schema from a struct (or pointer to struct)
+// using the same tags as Bind (cfg, default, validate).
+func GenerateStructSchema(v any) O {
+	return withSchemaDraft(typeSchema(reflect.TypeOf(v), ""))
+}
+
+func withSchemaDraft(schema O) O {
+	schema["$schema"] = SchemaDraft
+	return schema
+}
+
+// entrySchema generates the schema of the entry, omitting the default value of masked keys
+func entrySchema(key string, e *Entry, masked func(key string) bool) O {
+	if masked != nil && e.kind != ObjectKind && masked(key) {
+		schema := entrySchema(key, e, nil)
+		delete(schema, "default")
+		return schema
+	}
+
+	switch e.kind {
+	case BoolKind:
+		return O{"type": "boolean", "default": e.value}
+	case NumberKind:
+		return O{"type": "number", "default": e.value}
+	case StringKind:
+		if e.expr != "" {
+			return O{"type": "string", "default": e.expr}
+		}
+		return O{"type": "string", "default": e.value}
+	case ArrayKind:
+		schema := O{"type": "array"}
+		if list, _ := e.value.([]*Entry); len(list) > 0 {
+			items := entrySchema(key+"[0]", list[0], masked)
+			delete(items, "default")
+			schema["items"] = items
+		}
+		return schema
+	default:
+		properties := O{}
+		value, _ := e.value.(map[string]*Entry)
+		for k, entry := range value {
+			properties[k] = entrySchema(joinKey(key, Escape(k)), entry, masked)
+		}
+		return O{"type": "object", "properties": properties}
+	}
+}
+
+func typeSchema(t reflect.Type, tag reflect.StructTag) O {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var schema O
+	switch {
+	case t == durationType:
+		schema = O{"type": "string"}
+	case t == timeType:
+		schema = O{"type": "string", "format": "date-time"}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			schema = O{"type": "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema = O{"type": "integer"}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = O{"type": "integer", "minimum": 0}
+		case reflect.Float32, reflect.Float64:
+			schema = O{"type": "number"}
+		case reflect.String:
+			schema = O{"type": "string"}
+		case reflect.Slice, reflect.Array:
+			schema = O{"type": "array", "items": typeSchema(t.Elem(), "")}
+		case reflect.Map:
+			schema = O{"type": "object", "additionalProperties": typeSchema(t.Elem(), "")}
+		case reflect.Struct:
+			schema = structSchema(t)
+		default:
+			schema = O{}
+		}
+	}
+
+	if def, hasDef := tag.Lookup("default"); hasDef {
+		switch schema["type"] {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(def, 64); err == nil {
+				schema["default"] = f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(def); err == nil {
+				schema["default"] = b
+			}
+		case "array":
+			schema["default"] = defaultValue(def, t)
+		default:
+			schema["default"] = def
+		}
+	}
+
+	for _, item := range splitRules(tag.Get("validate")) {
+		name, arg, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch name {
+		case "min", "max":
+			if f, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema[map[string]string{"min": "minimum", "max": "maximum"}[name]] = f
+			}
+		case "oneof":
+			var enum []any
+			for _, o := range strings.Fields(arg) {
+				enum = append(enum, o)
+			}
+			schema["enum"] = enum
+		case "regex":
+			schema["pattern"] = arg
+		case "url":
+			schema["format"] = "uri"
+		}
+	}
+	return schema
+}
+
+func structSchema(t reflect.Type) O {
+	properties := O{}
+	var required []any
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, hasTag := field.Tag.Lookup("cfg")
+			if name == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && !hasTag {
+				ft := field.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					visit(ft)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type, field.Tag)
+			for _, item := range splitRules(field.Tag.Get("validate")) {
+				if strings.TrimSpace(item) == "required" {
+					required = append(required, name)
+				}
+			}
+		}
+	}
+	visit(t)
+
+	schema := O{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// validateSchema validates the value (bool, float64, string, []any, map[string]any) against the schema
+func validateSchema(key string, value any, schema map[string]any) (errs ValidationErrors) {
+	fail := func(format string, args ...any) {
+		errs = append(errs, &ValidationError{Key: key, Err: fmt.Errorf(format, args...)})
+	}
+
+	if t, exist := schema["type"]; exist {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []any:
+			for _, it := range tt {
+				types = append(types, fmt.Sprint(it))
+			}
+		}
+		valid := false
+		for _, it := range types {
+			if schemaTypeOf(value, it) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			fail("must be of type %s", strings.Join(types, " or "))
+			return
+		}
+	}
+
+	if enum, isList := schema["enum"].([]any); isList {
+		valid := false
+		for _, it := range enum {
+			if reflect.DeepEqual(normalizeSchemaValue(it), value) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			fail("must be one of %v", enum)
+		}
+	}
+
+	if constant, exist := schema["const"]; exist && !reflect.DeepEqual(normalizeSchemaValue(constant), value) {
+		fail("must be equal to %v", constant)
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			fail("must be greater than or equal to %v", n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			fail("must be less than or equal to %v", n)
+		}
+		if n, ok := schemaNumber(schema["exclusiveMinimum"]); ok && v <= n {
+			fail("must be greater than %v", n)
+		}
+		if n, ok := schemaNumber(schema["exclusiveMaximum"]); ok && v >= n {
+			fail("must be less than %v", n)
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			fail("length must be greater than or equal to %v", n)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			fail("length must be less than or equal to %v", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil {
+				fail("invalid pattern %q in schema", pattern)
+			} else if !re.MatchString(v) {
+				fail("must match %q", pattern)
+			}
+		}
+	case []any:
+		length := float64(len(v))
+		if n, ok := schemaNumber(schema["minItems"]); ok && length < n {
+			fail("must have at least %v items", n)
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && length > n {
+			fail("must have at most %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, it := range v {
+				errs = append(errs, validateSchema(fmt.Sprintf("%s[%d]", key, i), it, items)...)
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, exist := v[fmt.Sprint(name)]; !exist {
+					errs = append(errs, &ValidationError{
+						Key: joinKey(key, Escape(fmt.Sprint(name))),
+						Err: errors.New("is required"),
+					})
+				}
+			}
+		}
+
+		var names []string
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			pkey := joinKey(key, Escape(name))
+			if ps, exist := properties[name].(map[string]any); exist {
+				errs = append(errs, validateSchema(pkey, v[name], ps)...)
+			} else if _, exist = properties[name]; exist {
+				continue
+			} else if ap, isSchema := schema["additionalProperties"].(map[string]any); isSchema {
+				errs = append(errs, validateSchema(pkey, v[name], ap)...)
+			} else if ap, isBool := schema["additionalProperties"].(bool); isBool && !ap {
+				errs = append(errs, &ValidationError{Key: pkey, Err: errors.New("is not allowed")})
+			}
+		}
+	}
+
+	if list, ok := schema["allOf"].([]any); ok {
+		for _, it := range list {
+			if sub, isSchema := it.(map[string]any); isSchema {
+				errs = append(errs, validateSchema(key, value, sub)...)
+			}
+		}
+	}
+	if list, ok := schema["anyOf"].([]any); ok {
+		if countValidSchemas(key, value, list) == 0 {
+			fail("must match at least one schema (anyOf)")
+		}
+	}
+	if list, ok := schema["oneOf"].([]any); ok {
+		if countValidSchemas(key, value, list) != 1 {
+			fail("must match exactly one schema (oneOf)")
+		}
+	}
+	if sub, ok := schema["not"].(map[string]any); ok {
+		if len(validateSchema(key, value, sub)) == 0 {
+			fail("must not match the schema (not)")
+		}
+	}
+	return
+}
+
+func countValidSchemas(key string, value any, list []any) (count int) {
+	for _, it := range list {
+		if sub, isSchema := it.(map[string]any); isSchema && len(validateSchema(key, value, sub)) == 0 {
+			count++
+		}
+	}
+	return
+}
+
+func schemaTypeOf(value any, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+func schemaNumber(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	f, err := toFloat(normalizeSchemaValue(v))
+	return f, err == nil
+}
+
+// normalizeSchemaValue converts values produced by other unmarshallers (ex. yaml ints) to the Entry types
+func normalizeSchemaValue(v any) any {
+	if e := ParseEntry(v); e != nil {
+		return e.Value()
+	}
+	return v
+}
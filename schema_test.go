@@ -0,0 +1,102 @@
+package cfg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEnv_ValidateSchema(t *testing.T) {
+	fs := http.FS(fstest.MapFS{
+		"schema.json": {Data: []byte(`{
+			"type": "object",
+			"required": ["server", "name"],
+			"properties": {
+				"server": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {
+						"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+						"mode": {"enum": ["dev", "prod"]},
+						"host": {"type": "string", "pattern": "^[a-z]+$"}
+					}
+				},
+				"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+			}
+		}`)},
+	})
+
+	env := New(O{
+		"server": O{"port": 80000, "mode": "test", "host": "localhost", "extra": true},
+		"tags":   []any{"a", 1, "c"},
+	})
+	env.SetFileSystem(fs)
+
+	err := env.ValidateSchema("schema.json")
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidateSchema() error = %v, want ValidationErrors", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Key)
+	}
+	want := []string{"name", "server.extra", "server.mode", "server.port", "tags", "tags[1]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateSchema() = %v, want %v", got, want)
+	}
+
+	valid := New(O{"name": "app", "server": O{"port": 8080, "mode": "dev", "host": "localhost"}})
+	valid.SetFileSystem(fs)
+	if err = valid.ValidateSchema("schema.json"); err != nil {
+		t.Errorf("ValidateSchema() error = %v", err)
+	}
+
+	if err = valid.ValidateSchema("missing.json"); err == nil {
+		t.Errorf("ValidateSchema() expected error for missing schema")
+	}
+
+	// the expressions are expanded before the validation
+	valid.Set("server.host", "${host:?must be set}")
+	var exprErr *ExpressionError
+	if err = valid.ValidateSchema("schema.json"); !errors.As(err, &exprErr) {
+		t.Errorf("ValidateSchema() error = %v, want *ExpressionError", err)
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema := GenerateSchema(O{
+		"server": O{"port": 8080, "debug": false, "host": "${HOST}"},
+		"tags":   []string{"a"},
+	})
+
+	got, _ := json.Marshal(schema)
+	want := `{"$schema":"http://json-schema.org/draft-07/schema#","properties":{"server":{"properties":{"debug":{"default":false,"type":"boolean"},"host":{"default":"${HOST}","type":"string"},"port":{"default":8080,"type":"number"}},"type":"object"},"tags":{"items":{"type":"string"},"type":"array"}},"type":"object"}`
+	if string(got) != want {
+		t.Errorf("GenerateSchema() = %s, want %s", got, want)
+	}
+}
+
+func TestGenerateStructSchema(t *testing.T) {
+	type server struct {
+		Port    int           `cfg:"port" default:"8080" validate:"required,min=1,max=65535"`
+		Mode    string        `cfg:"mode" validate:"oneof=dev prod"`
+		Code    string        `cfg:"code" validate:"required,regex=^[a-z]{1,3}$"`
+		Timeout time.Duration `cfg:"timeout"`
+		Started time.Time     `cfg:"started"`
+		Tags    []string      `cfg:"tags"`
+		Labels  map[string]uint
+	}
+
+	got, _ := json.Marshal(GenerateStructSchema(&server{}))
+	want := `{"$schema":"http://json-schema.org/draft-07/schema#","properties":{"Labels":{"additionalProperties":{"minimum":0,"type":"integer"},"type":"object"},"code":{"pattern":"^[a-z]{1,3}$","type":"string"},"mode":{"enum":["dev","prod"],"type":"string"},"port":{"default":8080,"maximum":65535,"minimum":1,"type":"integer"},"started":{"format":"date-time","type":"string"},"tags":{"items":{"type":"string"},"type":"array"},"timeout":{"type":"string"}},"required":["port","code"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("GenerateStructSchema() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Secret a sensitive string, redacted when printed, logged or serialized. Use Reveal to get the value.
+type Secret string
+
+// String implements fmt.Stringer, returns MaskedValue
+func (s Secret) String() string {
+	return MaskedValue
+}
+
+// GoString implements fmt.GoStringer (%#v), returns MaskedValue
+func (s Secret) GoString() string {
+	return strconv.Quote(MaskedValue)
+}
+
+// MarshalJSON implements json.Marshaler, returns MaskedValue
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MaskedValue)
+}
+
+// MarshalYAML implements yaml.Marshaler, returns MaskedValue
+func (s Secret) MarshalYAML() (any, error) {
+	return MaskedValue, nil
+}
+
+// LogValue implements slog.LogValuer, returns MaskedValue
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(MaskedValue)
+}
+
+// Reveal returns the real value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// MarkSensitive registers glob patterns (see MatchKey) of keys with sensitive values. Values
+// of these keys (and their children) are redacted in logs, errors, Dump, Explain and Schema.
+//
+//	env.MarkSensitive("**.password", "**.secret*", "**.token")
+func (c *Env) MarkSensitive(patterns ...string) {
+	c.sensitiveMutex.Lock()
+	defer c.sensitiveMutex.Unlock()
+
+	c.sensitive = append(c.sensitive, patterns...)
+}
+
+// IsSensitive checks if the key (or one of its parents) matches a pattern defined by MarkSensitive
+func (c *Env) IsSensitive(key string) bool {
+	c.sensitiveMutex.RLock()
+	defer c.sensitiveMutex.RUnlock()
+
+	if len(c.sensitive) == 0 {
+		return false
+	}
+
+	segments := Segments(key)
+	for i := len(segments); i > 0; i-- {
+		candidates := []string{joinSegments(segments[:i])}
+		if idx := strings.IndexByte(segments[i-1], '['); idx > 0 {
+			// "db.passwords[0]" => "db.passwords"
+			parent := append(append([]string{}, segments[:i-1]...), segments[i-1][:idx])
+			candidates = append(candidates, joinSegments(parent))
+		}
+		for _, pattern := range c.sensitive {
+			for _, candidate := range candidates {
+				if MatchKey(pattern, candidate) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Secret get a string value as Secret
+func (c *Env) Secret(key string, def ...string) Secret {
+	return Secret(c.String(key, def...))
+}
+
+// logValue the value, redacted when the key is sensitive
+func (c *Env) logValue(key string, value any) any {
+	if c.IsSensitive(key) {
+		return Secret(fmt.Sprint(value))
+	}
+	return value
+}
+
+// conversionError creates a ConversionError, redacting the value when the key is sensitive
+func (c *Env) conversionError(key string, value any, typ string, err error) error {
+	if c.IsSensitive(key) {
+		if err != nil {
+			err = redact(err, c.toString(key, value))
+		}
+		value = Secret(fmt.Sprint(value))
+	}
+	return &ConversionError{Key: key, Value: value, Type: typ, Err: err}
+}
+
+// redactError removes the values of all sensitive keys and the values (optional) from the error message
+func (c *Env) redactError(err error, values ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	c.mutex.RLock()
+	c.root.walkKeys("", func(key string, e *Entry) {
+		if s, isString := e.rawValue().(string); isString && len(s) > 2 && c.IsSensitive(key) {
+			values = append(values, s)
+		}
+	})
+	c.mutex.RUnlock()
+
+	return redact(err, values...)
+}
+
+// sensitiveValues the values of the sensitive keys in the content of a file that cannot be parsed (Ex.
+// `password = "x"` or `"password": "x"`), the keys are matched by the last segment of the patterns
+func (c *Env) sensitiveValues(content []byte) []string {
+	c.sensitiveMutex.RLock()
+	var names []string
+	for _, pattern := range c.sensitive {
+		names = append(names, strings.ToLower(pattern[strings.LastIndexByte(pattern, '.')+1:]))
+	}
+	c.sensitiveMutex.RUnlock()
+
+	var values []string
+	for _, line := range strings.Split(string(content), "\n") {
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 || len(names) == 0 {
+			continue
+		}
+		name := strings.TrimRight(strings.TrimSpace(line[:i]), `"'`)
+		name = strings.ToLower(name[strings.LastIndexAny(name, ".\"'{, \t")+1:])
+		value := strings.Trim(strings.TrimSpace(line[i+1:]), `"',;}`)
+		for _, pattern := range names {
+			if len(value) > 2 && MatchKey(pattern, name) {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// redactedError an error without the sensitive values. The wrapped errors are also redacted, the original
+// error is not exposed.
+type redactedError struct {
+	msg    string
+	err    error
+	values []string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() []error {
+	var errs []error
+	switch err := e.err.(type) {
+	case interface{ Unwrap() error }:
+		if wrapped := err.Unwrap(); wrapped != nil {
+			errs = append(errs, redact(wrapped, e.values...))
+		}
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range err.Unwrap() {
+			errs = append(errs, redact(wrapped, e.values...))
+		}
+	}
+	return errs
+}
+
+// redact replaces the values in the error message with MaskedValue
+func redact(err error, values ...string) error {
+	msg := redactString(err.Error(), values...)
+	if msg == err.Error() {
+		return err
+	}
+	switch e := err.(type) {
+	case *redactedError:
+		return &redactedError{msg: msg, err: e.err, values: append(append([]string{}, e.values...), values...)}
+	case *SyntaxError:
+		// keeps the type, the message is the only content of the file
+		redacted := *e
+		redacted.Msg = redactString(e.Msg, values...)
+		return &redacted
+	}
+	return &redactedError{msg: msg, err: err, values: values}
+}
+
+func redactString(s string, values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			s = strings.ReplaceAll(s, value, MaskedValue)
+		}
+	}
+	return s
+}
+
+func joinSegments(segments []string) string {
+	var out string
+	for _, s := range segments {
+		out = joinKey(out, Escape(s))
+	}
+	return out
+}
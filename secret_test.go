@@ -0,0 +1,185 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("p4ssw0rd")
+
+	if got := fmt.Sprintf("%v %s %#v", s, s, s); got != `****** ****** "******"` {
+		t.Errorf("Sprintf() = %v", got)
+	}
+	if got, _ := json.Marshal(O{"password": s}); string(got) != `{"password":"******"}` {
+		t.Errorf("json.Marshal() = %s", got)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("test", slog.Any("password", s))
+	if strings.Contains(buf.String(), "p4ssw0rd") {
+		t.Errorf("slog = %v", buf.String())
+	}
+
+	if got := s.Reveal(); got != "p4ssw0rd" {
+		t.Errorf("Reveal() = %v, want %v", got, "p4ssw0rd")
+	}
+}
+
+func TestEnv_IsSensitive(t *testing.T) {
+	env := New()
+	env.MarkSensitive("**.password", "api.keys", "secrets")
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"db.password", true},
+		{"app.db.password", true},
+		{"db.password.old", true},
+		{"db.user", false},
+		{"api.keys", true},
+		{"api.keys[0]", true},
+		{"api.keys[0].value", true},
+		{"api.url", false},
+		{"secrets.token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := env.IsSensitive(tt.key); got != tt.want {
+				t.Errorf("IsSensitive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_Secret_Redacted(t *testing.T) {
+	env := New(O{"db": O{"password": "p4ssw0rd", "port": "p4ssw0rd"}})
+	env.MarkSensitive("**.password")
+
+	if got := env.Secret("db.password").Reveal(); got != "p4ssw0rd" {
+		t.Errorf("Secret() = %v, want %v", got, "p4ssw0rd")
+	}
+
+	t.Run("ConversionError", func(t *testing.T) {
+		_, err := env.LookupInt("db.password")
+		var convErr *ConversionError
+		if !errors.As(err, &convErr) {
+			t.Fatalf("LookupInt() error = %v, want *ConversionError", err)
+		}
+		if strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Errorf("LookupInt() error = %v", err)
+		}
+
+		// not sensitive
+		if _, err = env.LookupInt("db.port"); !strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Errorf("LookupInt() error = %v", err)
+		}
+	})
+
+	t.Run("Bind", func(t *testing.T) {
+		var db struct {
+			Password int `cfg:"password"`
+		}
+		if err := env.Bind("db", &db); err == nil || strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Errorf("Bind() error = %v", err)
+		}
+	})
+
+	t.Run("Dump", func(t *testing.T) {
+		if got := env.Explain("db.password"); strings.Contains(got, "p4ssw0rd") {
+			t.Errorf("Explain() = %v", got)
+		}
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		got, _ := json.Marshal(env.Schema())
+		if strings.Count(string(got), "p4ssw0rd") != 1 {
+			t.Errorf("Schema() = %s", got)
+		}
+	})
+
+	t.Run("Clone", func(t *testing.T) {
+		var out strings.Builder
+		if err := env.Clone().Dump(&out, DumpOptions{}); err != nil {
+			t.Fatalf("Dump() error = %v", err)
+		}
+		if strings.Count(out.String(), "p4ssw0rd") != 1 {
+			t.Errorf("Dump() = %v", out.String())
+		}
+	})
+}
+
+func TestEnv_redactError(t *testing.T) {
+	env := New(O{"db": O{"password": "p4ssw0rd"}})
+	env.MarkSensitive("db.password")
+
+	err := env.redactError(fmt.Errorf("invalid token p4ssw0rd: %w", ErrKeyNotFound))
+	if got := err.Error(); got != "invalid token ******: cfg: key not found" {
+		t.Errorf("redactError() = %v", got)
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("redactError() must unwrap the original error")
+	}
+
+	// the wrapped errors are also redacted
+	err = env.redactError(fmt.Errorf("load: %w", fmt.Errorf("invalid token p4ssw0rd: %w", ErrKeyNotFound)))
+	for e := err; e != nil; {
+		if strings.Contains(e.Error(), "p4ssw0rd") {
+			t.Errorf("redactError() wrapped = %v", e)
+		}
+		wrapped := e.(interface{ Unwrap() []error }).Unwrap()
+		if e = nil; len(wrapped) > 0 {
+			e = wrapped[0]
+		}
+		if e == ErrKeyNotFound {
+			break
+		}
+	}
+}
+
+func TestEnv_Load_RedactedSyntaxError(t *testing.T) {
+	env := New()
+	env.MarkSensitive("**.password")
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.ini": {Data: []byte("[db]\npassword = s3cr3t\ns3cr3t\n")},
+	}))
+	env.SetFileExt("ini", IniUnmarshal)
+
+	err := env.LoadFiles()
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("LoadFiles() error = %v, want *SyntaxError", err)
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("LoadFiles() error = %v", err)
+	}
+}
+
+func TestEnv_Load_Redacted(t *testing.T) {
+	env := New()
+	env.MarkSensitive("**.password")
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.json":    {Data: []byte(`{"db": {"password": "p4ssw0rd"}}`)},
+		"config-dev.txt": {Data: []byte(`password p4ssw0rd`)},
+	}))
+	env.Set("profiles", "dev")
+	env.SetFileExt("txt", func(data []byte) (map[string]any, error) {
+		return nil, fmt.Errorf("unexpected %q", data)
+	})
+
+	err := env.Load()
+	if err == nil {
+		t.Fatal("Load() must fail")
+	}
+	if strings.Contains(err.Error(), "p4ssw0rd") {
+		t.Errorf("Load() error = %v", err)
+	}
+}
@@ -0,0 +1,488 @@
+package cfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HclUnmarshal parses an HCL (HashiCorp Configuration Language) document. Attributes are mapped to
+// keys, labeled blocks to nested objects (service "http" { ... } => service.http) and repeated blocks
+// to arrays. Only literal values are supported (strings, heredocs, numbers, booleans, null, lists and
+// objects), interpolations (${...}) are kept in the strings and expanded by Env.
+func HclUnmarshal(content []byte) (map[string]any, error) {
+	p := &hclParser{src: content}
+	body, err := p.parseBody(false)
+	if err != nil {
+		return nil, err
+	}
+	return hclFinalize(body).(map[string]any), nil
+}
+
+// hclBlocks bodies of the blocks with the same type and labels, converted to an array when repeated
+type hclBlocks []map[string]any
+
+func hclFinalize(v any) any {
+	switch t := v.(type) {
+	case hclBlocks:
+		if len(t) == 1 {
+			return hclFinalize(t[0])
+		}
+		list := make([]any, len(t))
+		for i, body := range t {
+			list[i] = hclFinalize(body)
+		}
+		return list
+	case map[string]any:
+		for k, item := range t {
+			t[k] = hclFinalize(item)
+		}
+		return t
+	case []any:
+		for i, item := range t {
+			t[i] = hclFinalize(item)
+		}
+		return t
+	}
+	return v
+}
+
+type hclParser struct {
+	src []byte
+	pos int
+}
+
+func (p *hclParser) errorf(msg string, args ...any) error {
+	return syntaxError("hcl", p.src, p.pos, msg, args...)
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// consume advances when the content starts with the prefix
+func (p *hclParser) consume(prefix string) bool {
+	if strings.HasPrefix(string(p.src[p.pos:]), prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// skipSpace skips spaces, tabs and inline comments, stopping at the end of the line
+func (p *hclParser) skipSpace() error {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r':
+			p.pos++
+		case p.peek() == '#' || p.consume("//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case p.consume("/*"):
+			end := strings.Index(string(p.src[p.pos:]), "*/")
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 2
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipBlank skips whitespaces, newlines and comments
+func (p *hclParser) skipBlank() error {
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if !p.consume("\n") {
+			return nil
+		}
+	}
+}
+
+func isHclIdentifier(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= utf8.RuneSelf ||
+		(!first && (c == '-' || (c >= '0' && c <= '9')))
+}
+
+func (p *hclParser) parseIdentifier() string {
+	start := p.pos
+	for !p.eof() && isHclIdentifier(p.peek(), p.pos == start) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// parseBody parses attributes and blocks until the closing brace (nested) or the end of the content
+func (p *hclParser) parseBody(nested bool) (map[string]any, error) {
+	body := map[string]any{}
+	for {
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			if nested {
+				return nil, p.errorf("expected '}' at the end of the block")
+			}
+			return body, nil
+		}
+		if nested && p.consume("}") {
+			return body, nil
+		}
+
+		start := p.pos
+		name := p.parseIdentifier()
+		if name == "" {
+			return nil, p.errorf("expected an attribute or block, found %q", p.peek())
+		}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+
+		if p.consume("=") {
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if _, exist := body[name]; exist {
+				p.pos = start
+				return nil, p.errorf("attribute %q is already defined", name)
+			}
+			body[name] = value
+
+			if err = p.skipSpace(); err != nil {
+				return nil, err
+			}
+			if !p.eof() && p.peek() != '\n' && !(nested && p.peek() == '}') {
+				return nil, p.errorf("unexpected %q after attribute %q, expected a new line", p.peek(), name)
+			}
+			continue
+		}
+
+		// block: type "label" label {
+		path := []string{name}
+		for p.peek() != '{' {
+			var label string
+			if p.consume(`"`) {
+				s, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				label = s
+			} else if label = p.parseIdentifier(); label == "" {
+				return nil, p.errorf("expected '=' or a block, found %q", p.peek())
+			}
+			path = append(path, label)
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+		}
+		p.pos++
+		block, err := p.parseBody(true)
+		if err != nil {
+			return nil, err
+		}
+		if err = hclAddBlock(body, path, block); err != nil {
+			p.pos = start
+			return nil, p.errorf("%s", err)
+		}
+	}
+}
+
+// hclAddBlock adds the block body to the object at the path (type and labels)
+func hclAddBlock(body map[string]any, path []string, block map[string]any) error {
+	obj := body
+	for i, key := range path {
+		last := i == len(path)-1
+		switch v := obj[key].(type) {
+		case nil:
+			if last {
+				obj[key] = hclBlocks{block}
+				return nil
+			}
+			m := map[string]any{}
+			obj[key] = m
+			obj = m
+		case hclBlocks:
+			if last {
+				obj[key] = append(v, block)
+				return nil
+			}
+			obj = v[len(v)-1]
+		case map[string]any:
+			if last {
+				return fmt.Errorf("block %q conflicts with labeled blocks", strings.Join(path, " "))
+			}
+			obj = v
+		default:
+			return fmt.Errorf("block %q conflicts with the attribute %q", strings.Join(path, " "), key)
+		}
+	}
+	return nil
+}
+
+func (p *hclParser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		p.pos++
+		return p.parseString()
+	case c == '<' && p.consume("<<"):
+		return p.parseHeredoc()
+	case c == '[':
+		p.pos++
+		return p.parseList()
+	case c == '{':
+		p.pos++
+		return p.parseObject()
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && strings.IndexByte("0123456789.eE+-", p.peek()) >= 0 {
+			if (p.peek() == '+' || p.peek() == '-') && p.src[p.pos-1] != 'e' && p.src[p.pos-1] != 'E' {
+				break
+			}
+			p.pos++
+		}
+		token := string(p.src[start:p.pos])
+		if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+			return i, nil
+		} else if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return f, nil
+		}
+		p.pos = start
+		return nil, p.errorf("invalid number %q", token)
+	}
+
+	start := p.pos
+	switch p.parseIdentifier() {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	p.pos = start
+	return nil, p.errorf("unsupported expression, only literal values are allowed")
+}
+
+// parseString parses the quoted template after the opening quote, keeping ${...} and %{...}
+func (p *hclParser) parseString() (string, error) {
+	var sb strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == '"':
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		case (c == '$' || c == '%') && p.peek() == '{':
+			// interpolation, may contain quotes
+			depth := 0
+			start := p.pos - 1
+			for ; !p.eof(); p.pos++ {
+				if p.peek() == '{' {
+					depth++
+				} else if p.peek() == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				} else if p.peek() == '\n' {
+					return "", p.errorf("unterminated interpolation")
+				}
+			}
+			if p.eof() {
+				return "", p.errorf("unterminated interpolation")
+			}
+			p.pos++
+			sb.Write(p.src[start:p.pos])
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// parseEscape parses the escape sequence after the backslash
+func (p *hclParser) parseEscape(sb *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape %q", p.src[p.pos:p.pos+size])
+		}
+		p.pos += size
+		sb.WriteRune(rune(code))
+	default:
+		p.pos--
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// parseHeredoc parses the heredoc after "<<" (<<EOF or <<-EOF, that removes the common indentation)
+func (p *hclParser) parseHeredoc() (string, error) {
+	indent := p.consume("-")
+	marker := p.parseIdentifier()
+	if marker == "" {
+		return "", p.errorf("expected a heredoc marker")
+	}
+	p.consume("\r")
+	if !p.consume("\n") {
+		return "", p.errorf("expected a new line after the heredoc marker")
+	}
+
+	var lines []string
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated heredoc, expected %q", marker)
+		}
+		end := strings.IndexByte(string(p.src[p.pos:]), '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := strings.TrimSuffix(string(p.src[p.pos:p.pos+end]), "\r")
+		p.pos += end
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+		p.pos++
+		lines = append(lines, line)
+	}
+
+	if indent {
+		common := -1
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if n := len(line) - len(strings.TrimLeft(line, " \t")); common < 0 || n < common {
+				common = n
+			}
+		}
+		for i, line := range lines {
+			if len(line) >= common && common > 0 {
+				lines[i] = line[common:]
+			} else {
+				lines[i] = strings.TrimLeft(line, " \t")
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// parseList parses the list after the opening bracket
+func (p *hclParser) parseList() ([]any, error) {
+	list := []any{}
+	for {
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.consume("]") {
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		if err = p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.consume("]") {
+			return list, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or ']' in list, found %q", p.peek())
+		}
+	}
+}
+
+// parseObject parses the object after the opening brace, items are separated by commas or new lines
+func (p *hclParser) parseObject() (map[string]any, error) {
+	obj := map[string]any{}
+	for {
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.consume("}") {
+			return obj, nil
+		}
+
+		var key string
+		if p.consume(`"`) {
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		} else if key = p.parseIdentifier(); key == "" {
+			return nil, p.errorf("expected an object key, found %q", p.peek())
+		}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if !p.consume("=") && !p.consume(":") {
+			return nil, p.errorf("expected '=' or ':' after key %q", key)
+		}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = value
+
+		if err = p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if !p.consume(",") && !p.consume("\n") && p.peek() != '}' {
+			return nil, p.errorf("expected ',' or '}' in object, found %q", p.peek())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IniUnmarshal parses an INI document. Sections ([db] or [db.pool]) and dotted keys are mapped to
+// nested objects, keys ending with [] (Ex. "hosts[] = a") are appended to an array. Unquoted values
+// "true", "false" and numbers are converted, quoted values are always strings. Lines starting with
+// ';' or '#' are comments.
+//
+//	env.SetFileExt("ini", cfg.IniUnmarshal)
+func IniUnmarshal(content []byte) (map[string]any, error) {
+	root := map[string]any{}
+	var section []string
+
+	offset := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		start := offset
+		offset += len(line) + 1
+
+		text := strings.TrimSpace(string(line))
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+
+		if text[0] == '[' {
+			end := strings.IndexByte(text, ']')
+			if end < 0 {
+				return nil, syntaxError("ini", content, start, "expected ']' after section name")
+			}
+			if rest := strings.TrimSpace(text[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, syntaxError("ini", content, start, "unexpected %q after section", rest)
+			}
+			section = Segments(strings.TrimSpace(text[1:end]))
+			if _, err := nestedObject(root, section); err != nil {
+				return nil, syntaxError("ini", content, start, "%s", err)
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(text, "=:")
+		if sep <= 0 {
+			return nil, syntaxError("ini", content, start, "expected 'key = value', found %q", text)
+		}
+		key := strings.TrimSpace(text[:sep])
+		appendValue := strings.HasSuffix(key, "[]")
+		if appendValue {
+			key = strings.TrimSpace(strings.TrimSuffix(key, "[]"))
+		}
+
+		value, err := iniValue(strings.TrimSpace(text[sep+1:]))
+		if err != nil {
+			return nil, syntaxError("ini", content, start, "%s", err)
+		}
+
+		segments := append(append([]string{}, section...), Segments(key)...)
+		if err = nestedSet(root, segments, value, appendValue); err != nil {
+			return nil, syntaxError("ini", content, start, "%s", err)
+		}
+	}
+	return root, nil
+}
+
+// iniValue parses quoted strings, booleans and numbers, removing inline comments (" ;" and " #")
+func iniValue(text string) (any, error) {
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.LastIndexByte(text, text[0])
+		if end == 0 {
+			return nil, fmt.Errorf("unterminated string %s", text)
+		}
+		if rest := strings.TrimSpace(text[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+			return nil, fmt.Errorf("unexpected %q after string", rest)
+		}
+		if text[0] == '"' {
+			return strconv.Unquote(text[:end+1])
+		}
+		return text[1:end], nil
+	}
+
+	for i := 1; i < len(text); i++ {
+		if (text[i] == ';' || text[i] == '#') && (text[i-1] == ' ' || text[i-1] == '\t') {
+			text = strings.TrimSpace(text[:i])
+			break
+		}
+	}
+
+	switch strings.ToLower(text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXpPiInN_") {
+		return f, nil
+	}
+	return text, nil
+}
+
+// nestedObject finds (or creates) the object at the path
+func nestedObject(root map[string]any, segments []string) (map[string]any, error) {
+	obj := root
+	for i, segment := range segments {
+		switch v := obj[segment].(type) {
+		case nil:
+			m := map[string]any{}
+			obj[segment] = m
+			obj = m
+		case map[string]any:
+			obj = v
+		default:
+			return nil, fmt.Errorf("key %q is already defined as a value", joinSegments(segments[:i+1]))
+		}
+	}
+	return obj, nil
+}
+
+// nestedSet sets (or appends) the value at the path, creating the intermediate objects
+func nestedSet(root map[string]any, segments []string, value any, appendValue bool) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("empty key")
+	}
+	obj, err := nestedObject(root, segments[:len(segments)-1])
+	if err != nil {
+		return err
+	}
+
+	key := segments[len(segments)-1]
+	current, exist := obj[key]
+	if _, isObject := current.(map[string]any); isObject {
+		return fmt.Errorf("key %q is already defined as an object", joinSegments(segments))
+	}
+	if appendValue {
+		list, _ := current.([]any)
+		if exist && list == nil {
+			return fmt.Errorf("key %q is already defined as a value", joinSegments(segments))
+		}
+		value = append(list, value)
+	}
+	obj[key] = value
+	return nil
+}
@@ -0,0 +1,309 @@
+package cfg
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// json5Special prefix of the strings generated for Infinity and NaN, that JSON cannot represent
+const json5Special = `\u0000json5:`
+
+// Json5Unmarshal parses JSON with comments (// and /* */), trailing commas, unquoted keys,
+// single-quoted strings and the JSON5 numbers (hexadecimal, Infinity, NaN, leading or trailing
+// decimal point and leading "+"). Used for JSONC and JSON5 files, produces the same result as
+// JsonUnmarshal for plain JSON.
+func Json5Unmarshal(content []byte) (map[string]any, error) {
+	data, offsets, err := json5ToJson(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var config map[string]any
+	if err = json.Unmarshal(data, &config); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) {
+			return nil, syntaxError("json5", content, json5Offset(offsets, syntaxErr.Offset), "%s", syntaxErr)
+		} else if errors.As(err, &typeErr) {
+			return nil, syntaxError("json5", content, json5Offset(offsets, typeErr.Offset), "%s", typeErr)
+		}
+		return nil, err
+	}
+	if strings.Contains(string(data), json5Special) {
+		json5Numbers(config)
+	}
+	return config, nil
+}
+
+// json5Numbers replaces the strings generated for Infinity and NaN with their values
+func json5Numbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = json5Numbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = json5Numbers(item)
+		}
+	case string:
+		switch v {
+		case "\x00json5:Infinity", "\x00json5:+Infinity":
+			return math.Inf(1)
+		case "\x00json5:-Infinity":
+			return math.Inf(-1)
+		case "\x00json5:NaN", "\x00json5:+NaN", "\x00json5:-NaN":
+			return math.NaN()
+		}
+	}
+	return value
+}
+
+// json5Number converts the JSON5 number at the position i to JSON. Returns the position after the number.
+func json5Number(content []byte, i int, emit func(pos int, b ...byte)) (int, error) {
+	start := i
+	sign := ""
+	if content[i] == '+' || content[i] == '-' {
+		sign = string(content[i])
+		i++
+	}
+	isDigit := func(j int) bool {
+		return j < len(content) && content[j] >= '0' && content[j] <= '9'
+	}
+	isHex := func(j int) bool {
+		return isDigit(j) || (j < len(content) && strings.IndexByte("abcdefABCDEF", content[j]) >= 0)
+	}
+	emitString := func(s string) {
+		for j := 0; j < len(s); j++ {
+			emit(start, s[j])
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(string(content[i:]), "Infinity"), strings.HasPrefix(string(content[i:]), "NaN"):
+		word := "NaN"
+		if content[i] == 'I' {
+			word = "Infinity"
+		}
+		emitString(`"` + json5Special + sign + word + `"`)
+		return i + len(word), nil
+	case i+1 < len(content) && content[i] == '0' && (content[i+1] == 'x' || content[i+1] == 'X'):
+		j := i + 2
+		for isHex(j) {
+			j++
+		}
+		n, ok := new(big.Int).SetString(string(content[i+2:j]), 16)
+		if !ok {
+			return j, syntaxError("json5", content, start, "invalid hexadecimal number")
+		}
+		if sign == "-" {
+			n.Neg(n)
+		}
+		emitString(n.String())
+		return j, nil
+	}
+
+	// [digits][.digits][(e|E)[+|-]digits]
+	intStart := i
+	for isDigit(i) {
+		i++
+	}
+	intPart := string(content[intStart:i])
+	fracPart := ""
+	if i < len(content) && content[i] == '.' {
+		fracStart := i + 1
+		for i = fracStart; isDigit(i); i++ {
+		}
+		fracPart = string(content[fracStart:i])
+	}
+	if intPart == "" && fracPart == "" {
+		// not a number, reported by the JSON parser
+		emitString(string(content[start:i]))
+		return i, nil
+	}
+	expStart := i
+	if i < len(content) && (content[i] == 'e' || content[i] == 'E') {
+		i++
+		if i < len(content) && (content[i] == '+' || content[i] == '-') {
+			i++
+		}
+		for isDigit(i) {
+			i++
+		}
+	}
+
+	if sign == "-" {
+		emitString(sign)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	emitString(intPart)
+	if fracPart != "" {
+		emitString("." + fracPart)
+	}
+	emitString(string(content[expStart:i]))
+	return i, nil
+}
+
+// json5IsSpecial checks if the content starts with the value Infinity or NaN (not an unquoted key)
+func json5IsSpecial(content []byte) bool {
+	word := ""
+	for _, w := range []string{"Infinity", "NaN"} {
+		if strings.HasPrefix(string(content), w) {
+			word = w
+		}
+	}
+	if word == "" {
+		return false
+	}
+	rest := strings.TrimLeft(string(content[len(word):]), " \t\r\n")
+	return rest == "" || strings.IndexByte(",]}/", rest[0]) >= 0
+}
+
+// json5Offset converts the offset of the generated JSON to the offset of the original content
+func json5Offset(offsets []int, offset int64) int {
+	if offset <= 0 || len(offsets) == 0 {
+		return 0
+	}
+	if int(offset) > len(offsets) {
+		return offsets[len(offsets)-1] + 1
+	}
+	return offsets[offset-1]
+}
+
+// json5ToJson removes comments and trailing commas, quotes keys and converts single-quoted strings.
+// offsets maps each byte of the generated JSON to its position in the content.
+func json5ToJson(content []byte) (out []byte, offsets []int, err error) {
+	out = make([]byte, 0, len(content))
+	offsets = make([]int, 0, len(content))
+	emit := func(pos int, b ...byte) {
+		out = append(out, b...)
+		for range b {
+			offsets = append(offsets, pos)
+		}
+	}
+
+	// skip returns the position of the next significant byte, after whitespaces and comments
+	skip := func(i int) (int, error) {
+		for i < len(content) {
+			switch {
+			case content[i] == ' ' || content[i] == '\t' || content[i] == '\r' || content[i] == '\n':
+				i++
+			case i+1 < len(content) && content[i] == '/' && content[i+1] == '/':
+				for i < len(content) && content[i] != '\n' {
+					i++
+				}
+			case i+1 < len(content) && content[i] == '/' && content[i+1] == '*':
+				end := i + 2
+				for end+1 < len(content) && !(content[end] == '*' && content[end+1] == '/') {
+					end++
+				}
+				if end+1 >= len(content) {
+					return i, syntaxError("json5", content, i, "unterminated comment")
+				}
+				i = end + 2
+			default:
+				return i, nil
+			}
+		}
+		return i, nil
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '/':
+			next, errSkip := skip(i)
+			if errSkip != nil {
+				return nil, nil, errSkip
+			}
+			if next == i {
+				// a single '/', invalid JSON
+				emit(i, c)
+				next++
+			}
+			i = next
+		case c == ',':
+			next, errSkip := skip(i + 1)
+			if errSkip != nil {
+				return nil, nil, errSkip
+			}
+			if next < len(content) && (content[next] == '}' || content[next] == ']') {
+				// trailing comma
+				i++
+				continue
+			}
+			emit(i, c)
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			emit(i, '"')
+			for i++; i < len(content) && content[i] != c; i++ {
+				switch {
+				case content[i] == '\n':
+					return nil, nil, syntaxError("json5", content, start, "unterminated string")
+				case content[i] == '\\' && i+1 < len(content):
+					i++
+					switch content[i] {
+					case '\'':
+						emit(i, '\'')
+					case '\n':
+						// line continuation
+					case '\r':
+						if i+1 < len(content) && content[i+1] == '\n' {
+							i++
+						}
+					default:
+						emit(i-1, '\\', content[i])
+					}
+				case content[i] == '"':
+					emit(i, '\\', '"')
+				default:
+					emit(i, content[i])
+				}
+			}
+			if i >= len(content) {
+				return nil, nil, syntaxError("json5", content, start, "unterminated string")
+			}
+			emit(i, '"')
+			i++
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9') || ((c == 'I' || c == 'N') && json5IsSpecial(content[i:])):
+			next, errNumber := json5Number(content, i, emit)
+			if errNumber != nil {
+				return nil, nil, errNumber
+			}
+			i = next
+		case c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(content) && (content[i] == '_' || content[i] == '$' || (content[i] >= 'a' && content[i] <= 'z') ||
+				(content[i] >= 'A' && content[i] <= 'Z') || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			next, errSkip := skip(i)
+			if errSkip != nil {
+				return nil, nil, errSkip
+			}
+			if next < len(content) && content[next] == ':' {
+				// unquoted key
+				emit(start, '"')
+				for j := start; j < i; j++ {
+					emit(j, content[j])
+				}
+				emit(i, '"')
+			} else {
+				// true, false, null
+				for j := start; j < i; j++ {
+					emit(j, content[j])
+				}
+			}
+		default:
+			emit(i, c)
+			i++
+		}
+	}
+	return out, offsets, nil
+}
@@ -0,0 +1,116 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PropertiesUnmarshal parses a Java .properties document. Dotted keys are expanded into nested
+// objects, use "\." for dots that are part of the key (see Escape and Segments). Values are
+// always strings.
+//
+//	env.SetFileExt("properties", cfg.PropertiesUnmarshal)
+func PropertiesUnmarshal(content []byte) (map[string]any, error) {
+	root := map[string]any{}
+
+	lines := bytes.Split(content, []byte("\n"))
+	offset := 0
+	for i := 0; i < len(lines); i++ {
+		start := offset
+		offset += len(lines[i]) + 1
+
+		text := strings.TrimLeft(strings.TrimRight(string(lines[i]), "\r"), " \t\f")
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+
+		// a line ending with an odd number of backslashes continues on the next line
+		for propertiesContinues(text) && i+1 < len(lines) {
+			i++
+			offset += len(lines[i]) + 1
+			text = text[:len(text)-1] + strings.TrimLeft(strings.TrimRight(string(lines[i]), "\r"), " \t\f")
+		}
+
+		// the key ends at the first unescaped '=', ':' or whitespace
+		end := 0
+		for end < len(text) && strings.IndexByte("=: \t\f", text[end]) < 0 {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end > len(text) {
+			end = len(text)
+		}
+		key, value := text[:end], strings.TrimLeft(text[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+
+		key, err := propertiesUnescape(key, true)
+		if err != nil {
+			return nil, syntaxError("properties", content, start, "%s", err)
+		}
+		if value, err = propertiesUnescape(value, false); err != nil {
+			return nil, syntaxError("properties", content, start, "%s", err)
+		}
+		if err = nestedSet(root, Segments(key), value, false); err != nil {
+			return nil, syntaxError("properties", content, start, "%s", err)
+		}
+	}
+	return root, nil
+}
+
+func propertiesContinues(text string) bool {
+	count := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// propertiesUnescape decodes the escape sequences, keeping "\." in keys (see Segments)
+func propertiesUnescape(s string, key bool) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+			sb.WriteRune(rune(code))
+			i += 4
+		case '.':
+			if key {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
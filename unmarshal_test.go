@@ -0,0 +1,561 @@
+package cfg
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const unmarshalJson = `{
+	"title": "My App",
+	"debug": true,
+	"db": {
+		"host": "localhost",
+		"port": 5432,
+		"ratio": 0.75,
+		"pool": {"size": 10}
+	},
+	"tags": ["a", "b"]
+}`
+
+func TestUnmarshal_Formats(t *testing.T) {
+	want, err := JsonUnmarshal([]byte(unmarshalJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		unmarshal UnmarshalFn
+		content   string
+	}{
+		{"yaml", YamlUnmarshal, "title: My App\ndebug: true\ndb:\n  host: localhost\n  port: 5432\n  ratio: 0.75\n  pool:\n    size: 10\ntags: [a, b]\n"},
+		{"toml", TomlUnmarshal, `
+# comment
+title = "My App"
+debug = true # inline comment
+tags = [
+  "a",
+  'b', # trailing comma
+]
+
+[db]
+host = "localhost"
+port = 5_432
+ratio = 0.75
+pool.size = 10
+`},
+		{"toml inline", TomlUnmarshal, "title = 'My App'\r\ndebug = true\r\ntags = ['a', 'b']\r\ndb = { host = \"localhost\", port = 0x1538, ratio = 75e-2, pool = { size = 10 } }\r\n"},
+		{"ini", IniUnmarshal, `
+; comment
+title = "My App"
+debug = true
+tags[] = a
+tags[] = b
+
+[db]
+host = localhost ; inline comment
+port = 5432
+ratio: 0.75
+
+[db.pool]
+size = 10
+`},
+		{"ini dotted", IniUnmarshal, "title='My App'\r\ndebug=TRUE\r\ntags[]=a\r\ntags[]=b\r\ndb.host=localhost\r\ndb.port=5432\r\ndb.ratio=0.75\r\ndb.pool.size=10\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.unmarshal([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("unmarshal() error = %v", err)
+			}
+			if g, w := ParseEntry(got).Value(), ParseEntry(want).Value(); !reflect.DeepEqual(g, w) {
+				t.Errorf("unmarshal() = %v, want %v", g, w)
+			}
+		})
+	}
+}
+
+func TestTomlUnmarshal(t *testing.T) {
+	content := `
+str1 = "Roses are red\nViolets are \"blue\" \u00e9"
+str2 = """
+The quick brown \
+    fox jumps over \
+    the lazy dog."""
+str3 = '''
+C:\Users\nodejs\templates
+'''
+str4 = """Here are two quotation marks: "". Simple enough."""
+"quoted.key" = 1
+site."google.com" = true
+int = +99
+neg = -17
+oct = 0o755
+bin = 0b1101
+float = -6.626e-34
+inf = -inf
+date = 1979-05-27
+datetime = 1979-05-27 07:32:00Z
+time = 07:32:00
+nested = [[1, 2], ["a"]]
+empty = []
+
+[[products]]
+name = "Hammer"
+
+[[products]]
+
+[[products]]
+name = "Nail"
+dims = { w = 1, h = 2 }
+
+[products.color]
+name = "gray"
+`
+	got, err := TomlUnmarshal([]byte(content))
+	if err != nil {
+		t.Fatalf("TomlUnmarshal() error = %v", err)
+	}
+	if inf, _ := got["inf"].(float64); !math.IsInf(inf, -1) {
+		t.Errorf("TomlUnmarshal() inf = %v", got["inf"])
+	}
+	delete(got, "inf")
+
+	want := map[string]any{
+		"str1":       "Roses are red\nViolets are \"blue\" é",
+		"str2":       "The quick brown fox jumps over the lazy dog.",
+		"str3":       "C:\\Users\\nodejs\\templates\n",
+		"str4":       `Here are two quotation marks: "". Simple enough.`,
+		"quoted.key": int64(1),
+		"site":       map[string]any{"google.com": true},
+		"int":        int64(99),
+		"neg":        int64(-17),
+		"oct":        int64(0755),
+		"bin":        int64(13),
+		"float":      -6.626e-34,
+		"date":       "1979-05-27",
+		"datetime":   "1979-05-27T07:32:00Z",
+		"time":       "07:32:00",
+		"nested":     []any{[]any{int64(1), int64(2)}, []any{"a"}},
+		"empty":      []any{},
+		"products": []any{
+			map[string]any{"name": "Hammer"},
+			map[string]any{},
+			map[string]any{
+				"name":  "Nail",
+				"dims":  map[string]any{"w": int64(1), "h": int64(2)},
+				"color": map[string]any{"name": "gray"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TomlUnmarshal() = %v, want %v", got, want)
+	}
+}
+
+func TestPropertiesUnmarshal(t *testing.T) {
+	content := "# comment\n" +
+		"! comment\n" +
+		"title = My App\n" +
+		"db.host:localhost\n" +
+		"db.port 5432\n" +
+		"db.url = jdbc:postgresql://localhost\\:5432/db\n" +
+		"logging.level.com\\.example = DEBUG\n" +
+		"message = hello \\\n" +
+		"          world\\u0021\n" +
+		"key\\ with\\ spaces = a\\tb\r\n" +
+		"empty =\n"
+
+	got, err := PropertiesUnmarshal([]byte(content))
+	if err != nil {
+		t.Fatalf("PropertiesUnmarshal() error = %v", err)
+	}
+	want := map[string]any{
+		"title":           "My App",
+		"db":              map[string]any{"host": "localhost", "port": "5432", "url": "jdbc:postgresql://localhost:5432/db"},
+		"logging":         map[string]any{"level": map[string]any{"com.example": "DEBUG"}},
+		"message":         "hello world!",
+		"key with spaces": "a\tb",
+		"empty":           "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PropertiesUnmarshal() = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshal_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name      string
+		unmarshal UnmarshalFn
+		content   string
+		line      int
+	}{
+		{"toml value", TomlUnmarshal, "a = 1\nb = nope\n", 2},
+		{"toml string", TomlUnmarshal, "a = 1\n\nb = \"open\n", 3},
+		{"toml duplicate", TomlUnmarshal, "a = 1\na = 2\n", 2},
+		{"toml table", TomlUnmarshal, "a = 1\n[a.b]\n", 2},
+		{"toml table twice", TomlUnmarshal, "[a]\nx = 1\n[b]\n[a]\ny = 2\n", 4},
+		{"toml array table twice", TomlUnmarshal, "[[a]]\n[a.b]\n[a.b]\n", 3},
+		{"toml trailing", TomlUnmarshal, "a = 1 2\n", 1},
+		{"ini section", IniUnmarshal, "a = 1\n[db\n", 2},
+		{"ini key", IniUnmarshal, "a = 1\nnope\n", 2},
+		{"ini conflict", IniUnmarshal, "db = 1\n[db]\n", 2},
+		{"properties conflict", PropertiesUnmarshal, "a.b = 1\n\na = 2\n", 3},
+		{"ini format verb", IniUnmarshal, "a%d = 1\n[a%d]\n", 2},
+		{"properties format verb", PropertiesUnmarshal, "a%d.b = 1\na%d = 2\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.unmarshal([]byte(tt.content))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("unmarshal() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("unmarshal() line = %v, want %v (%v)", syntaxErr.Line, tt.line, err)
+			}
+			if strings.Contains(err.Error(), "%!") {
+				t.Errorf("unmarshal() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestEnv_Load_Formats(t *testing.T) {
+	type Config struct {
+		Title string `cfg:"title"`
+		DB    struct {
+			Host  string  `cfg:"host"`
+			Port  int     `cfg:"port"`
+			Ratio float64 `cfg:"ratio"`
+		} `cfg:"db"`
+	}
+
+	files := map[string]UnmarshalFn{
+		"config.json":       JsonUnmarshal,
+		"config.json5":      Json5Unmarshal,
+		"config.toml":       TomlUnmarshal,
+		"config.ini":        IniUnmarshal,
+		"config.properties": PropertiesUnmarshal,
+		"config.xml":        XmlUnmarshal,
+	}
+	contents := map[string]string{
+		"config.json":       `{"title": "My App", "db": {"host": "localhost", "port": 5432, "ratio": 0.75}}`,
+		"config.json5":      `{title: 'My App', db: {host: 'localhost', port: 5432, ratio: 0.75,},}`,
+		"config.toml":       "title = \"My App\"\n[db]\nhost = \"localhost\"\nport = 5432\nratio = 0.75\n",
+		"config.ini":        "title = My App\n[db]\nhost = localhost\nport = 5432\nratio = 0.75\n",
+		"config.properties": "title=My App\ndb.host=localhost\ndb.port=5432\ndb.ratio=0.75\n",
+		"config.xml":        `<config title="My App"><db host="localhost"><port>5432</port><ratio>0.75</ratio></db></config>`,
+	}
+
+	var want Config
+	want.Title, want.DB.Host, want.DB.Port, want.DB.Ratio = "My App", "localhost", 5432, 0.75
+	for name, fn := range files {
+		t.Run(name, func(t *testing.T) {
+			env := New()
+			env.SetFileSystem(http.FS(fstest.MapFS{name: {Data: []byte(contents[name])}}))
+			env.SetFileExt("json", nil)
+			env.SetFileExt("yml", nil)
+			env.SetFileExt("yaml", nil)
+			env.SetFileExt(name[len("config."):], fn)
+			if err := env.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			var got Config
+			if err := env.Unmarshal(&got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	// the other formats are opt-in
+	env := New()
+	env.SetFileSystem(http.FS(fstest.MapFS{"config.json5": {Data: []byte(contents["config.json5"])}}))
+	if err := env.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := env.Get("title"); got != nil {
+		t.Errorf("Get() = %v, want nil", got)
+	}
+}
+
+func TestJson5Unmarshal(t *testing.T) {
+	want, err := JsonUnmarshal([]byte(unmarshalJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"json", unmarshalJson},
+		{"jsonc", `{
+	// line comment
+	"title": "My App", /* block
+	comment */
+	"debug": true,
+	"db": {
+		"host": "localhost",
+		"port": 5432,
+		"ratio": 0.75,
+		"pool": {"size": 10,},
+	},
+	"tags": ["a", "b",],
+}`},
+		{"json5", `{
+	title: 'My App',
+	debug: true,
+	db: {host: 'localhost', port: 5432, ratio: 0.75, pool: {size: 10}},
+	$tags: null,
+	tags: ['a', "b"] // comment
+}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Json5Unmarshal([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Json5Unmarshal() error = %v", err)
+			}
+			delete(got, "$tags")
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Json5Unmarshal() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	got, err := Json5Unmarshal([]byte(`{'it\'s': 'say "hi" // not a comment', "url": "http://a/*b*/"}`))
+	if err != nil {
+		t.Fatalf("Json5Unmarshal() error = %v", err)
+	}
+	if want := map[string]any{"it's": `say "hi" // not a comment`, "url": "http://a/*b*/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Json5Unmarshal() = %v, want %v", got, want)
+	}
+
+	// numbers
+	got, err = Json5Unmarshal([]byte(`{hex: 0x1F, neg: -0XFF, a: .5, b: 5., c: +1, d: -.5e1, e: 1.5E+2, f: 0, Infinity: -Infinity, nan: NaN, list: [+Infinity, 2]}`))
+	if err != nil {
+		t.Fatalf("Json5Unmarshal() error = %v", err)
+	}
+	if nan, _ := got["nan"].(float64); !math.IsNaN(nan) {
+		t.Errorf("Json5Unmarshal() nan = %v, want NaN", got["nan"])
+	}
+	delete(got, "nan")
+	want = map[string]any{
+		"hex": float64(31), "neg": float64(-255), "a": 0.5, "b": float64(5), "c": float64(1), "d": -5.0, "e": float64(150),
+		"f": float64(0), "Infinity": math.Inf(-1), "list": []any{math.Inf(1), float64(2)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Json5Unmarshal() = %v, want %v", got, want)
+	}
+	for _, content := range []string{`{a: 0x}`, `{a: .}`, `{a: +}`, `{a: Infinityx}`} {
+		if _, err = Json5Unmarshal([]byte(content)); err == nil {
+			t.Errorf("Json5Unmarshal(%s) expected error", content)
+		}
+	}
+
+	_, err = Json5Unmarshal([]byte("{\n  // comment\n  a: 1,\n  b: nope\n}"))
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 4 {
+		t.Errorf("Json5Unmarshal() error = %v, want *SyntaxError at line 4", err)
+	}
+
+	_, err = Json5Unmarshal([]byte(`{"a": %d}`))
+	if !errors.As(err, &syntaxErr) || strings.Contains(err.Error(), "%!") {
+		t.Errorf("Json5Unmarshal() error = %v, want *SyntaxError", err)
+	}
+}
+
+func TestJson5Positions(t *testing.T) {
+	content := "{\n  // comment\n  server: {\n    'port': 80, /* c */ host: 'localhost',\n  },\n}"
+	got := json5Positions([]byte(content))
+	want := map[string]position{
+		"server":      {line: 3, column: 3},
+		"server.port": {line: 4, column: 5},
+		"server.host": {line: 4, column: 25},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json5Positions() = %v, want %v", got, want)
+	}
+}
+
+func TestHclUnmarshal(t *testing.T) {
+	content := `
+# comment
+title = "My App"
+debug = true // comment
+tags  = [
+  "a",
+  "b",
+]
+
+/* block comment */
+db {
+  host  = "localhost"
+  port  = 5432
+  ratio = 0.75
+  pool { size = 10 }
+}
+`
+	want, err := JsonUnmarshal([]byte(unmarshalJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := HclUnmarshal([]byte(content))
+	if err != nil {
+		t.Fatalf("HclUnmarshal() error = %v", err)
+	}
+	if g, w := ParseEntry(got).Value(), ParseEntry(want).Value(); !reflect.DeepEqual(g, w) {
+		t.Errorf("HclUnmarshal() = %v, want %v", g, w)
+	}
+
+	content = `
+service "http" "web" {
+  listen = "0.0.0.0:80"
+  url    = "http://${app.host}/\"index\""
+}
+service "grpc" api {
+  listen = ":9090"
+}
+rule {
+  path = "/a"
+}
+rule {
+  path = "/b"
+}
+labels = { env = "prod", "team": "infra" }
+script = <<-EOT
+    echo hello
+      echo world
+    EOT
+optional = null
+`
+	got, err = HclUnmarshal([]byte(content))
+	if err != nil {
+		t.Fatalf("HclUnmarshal() error = %v", err)
+	}
+	want = map[string]any{
+		"service": map[string]any{
+			"http": map[string]any{"web": map[string]any{"listen": "0.0.0.0:80", "url": `http://${app.host}/"index"`}},
+			"grpc": map[string]any{"api": map[string]any{"listen": ":9090"}},
+		},
+		"rule":     []any{map[string]any{"path": "/a"}, map[string]any{"path": "/b"}},
+		"labels":   map[string]any{"env": "prod", "team": "infra"},
+		"script":   "echo hello\n  echo world\n",
+		"optional": nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HclUnmarshal() = %v, want %v", got, want)
+	}
+
+	invalid := []struct {
+		content string
+		line    int
+	}{
+		{"a = 1\nb = var.x\n", 2},
+		{"a = 1\na = 2\n", 2},
+		{"a = 1 2\n", 1},
+		{"a {\n  b = 1\n", 3},
+		{"a = 1\na {}\n", 2},
+	}
+	for _, tt := range invalid {
+		_, err = HclUnmarshal([]byte(tt.content))
+		if syntaxErr, ok := err.(*SyntaxError); !ok || syntaxErr.Line != tt.line {
+			t.Errorf("HclUnmarshal(%q) error = %v, want *SyntaxError at line %d", tt.content, err, tt.line)
+		}
+	}
+}
+
+func TestEnv_Load_Hcl(t *testing.T) {
+	env := New()
+	env.SetFileSystem(http.FS(fstest.MapFS{
+		"config.hcl":      {Data: []byte("profiles = \"prod\"\nserver {\n  port = 80\n  host = \"localhost\"\n}\n")},
+		"config-prod.hcl": {Data: []byte("server {\n  port = 443\n}\n")},
+	}))
+	env.SetFileExt("hcl", HclUnmarshal)
+	if err := env.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := env.Int("server.port"); got != 443 {
+		t.Errorf("Int() = %v, want %v", got, 443)
+	}
+	if got := env.String("server.host"); got != "localhost" {
+		t.Errorf("String() = %v, want %v", got, "localhost")
+	}
+}
+
+func TestXmlUnmarshal(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- comment -->
+<config xmlns="http://example.com/config" version="2">
+  <title>My App</title>
+  <debug>true</debug>
+  <db host="localhost">
+    <port>5432</port>
+    <password><![CDATA[p<4>ss]]></password>
+    <description lang="en">Main database</description>
+  </db>
+  <tags>a</tags>
+  <tags>b</tags>
+  <empty/>
+</config>`
+
+	got, err := XmlUnmarshal([]byte(content))
+	if err != nil {
+		t.Fatalf("XmlUnmarshal() error = %v", err)
+	}
+	want := map[string]any{
+		"version": "2",
+		"title":   "My App",
+		"debug":   "true",
+		"db": map[string]any{
+			"host":        "localhost",
+			"port":        "5432",
+			"password":    "p<4>ss",
+			"description": map[string]any{"lang": "en", "value": "Main database"},
+		},
+		"tags":  []any{"a", "b"},
+		"empty": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("XmlUnmarshal() = %v, want %v", got, want)
+	}
+
+	unmarshal := CreateXmlUnmarshalFn(XmlOptions{AttrPrefix: "@", TextKey: "#text"})
+	got, err = unmarshal([]byte(`<config><server port="80">localhost</server></config>`))
+	if err != nil {
+		t.Fatalf("CreateXmlUnmarshalFn() error = %v", err)
+	}
+	want = map[string]any{"server": map[string]any{"@port": "80", "#text": "localhost"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateXmlUnmarshalFn() = %v, want %v", got, want)
+	}
+
+	_, err = XmlUnmarshal([]byte("<config>\n  <a>1</a>\n  <b>2</c>\n</config>"))
+	if syntaxErr, ok := err.(*SyntaxError); !ok || syntaxErr.Line != 3 {
+		t.Errorf("XmlUnmarshal() error = %v, want *SyntaxError at line 3", err)
+	}
+
+	// keys of attributes conflicting with the text or the children
+	for _, content := range []string{
+		"<config>\n  <port value=\"80\">8080</port>\n</config>",
+		"<config>\n  <db host=\"a\"><host>b</host></db>\n</config>",
+	} {
+		if _, err = XmlUnmarshal([]byte(content)); !errors.As(err, new(*SyntaxError)) {
+			t.Errorf("XmlUnmarshal(%q) error = %v, want *SyntaxError", content, err)
+		}
+	}
+	got, err = XmlUnmarshal([]byte(`<config><port value="80"/></config>`))
+	if want = map[string]any{"port": map[string]any{"value": "80"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("XmlUnmarshal() = %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,489 @@
+package cfg
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TomlUnmarshal parses a TOML v1.0 document. Dates and times are kept as strings (RFC 3339),
+// use Env.Time to get them.
+//
+//	env.SetFileExt("toml", cfg.TomlUnmarshal)
+func TomlUnmarshal(content []byte) (map[string]any, error) {
+	p := &tomlParser{src: content}
+	root := map[string]any{}
+	current := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			p.pos++
+			array := p.consume("[")
+			p.skipSpace()
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if !p.consume("]") || (array && !p.consume("]")) {
+				return nil, p.errorf("expected ']' after table name")
+			}
+			if current, err = p.table(root, keys, array); err != nil {
+				return nil, err
+			}
+		} else if err := p.parseKeyValue(current); err != nil {
+			return nil, err
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+var tomlDateTime = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}|\d{2}:\d{2})`)
+
+type tomlParser struct {
+	src     []byte
+	pos     int
+	defined map[string]bool // tables defined by a header (Ex. "a.b", "servers[1].tls"), defined only once
+}
+
+func (p *tomlParser) errorf(msg string, args ...any) error {
+	return syntaxError("toml", p.src, p.pos, msg, args...)
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// consume advances when the content starts with the prefix
+func (p *tomlParser) consume(prefix string) bool {
+	if strings.HasPrefix(string(p.src[p.pos:]), prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.pos++
+	}
+}
+
+// skipComment skips the comment until the end of the line
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespaces, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if !p.consume("\n") && !p.consume("\r\n") {
+			return
+		}
+	}
+}
+
+// endOfLine expects nothing but a comment until the end of the line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.eof() || p.consume("\n") || p.consume("\r\n") {
+		return nil
+	}
+	return p.errorf("unexpected %q, expected a new line", p.peek())
+}
+
+// table finds (or creates) the table of the header [a.b] or [[a.b]]
+func (p *tomlParser) table(root map[string]any, keys []string, array bool) (map[string]any, error) {
+	t := root
+	id := ""
+	for i, key := range keys {
+		last := i == len(keys)-1
+		id = joinKey(id, Escape(key))
+		switch v := t[key].(type) {
+		case nil:
+			m := map[string]any{}
+			if last && array {
+				t[key] = []any{m}
+			} else {
+				t[key] = m
+			}
+			t = m
+		case map[string]any:
+			if last && array {
+				return nil, p.errorf("key %q is already defined as a table", strings.Join(keys[:i+1], "."))
+			}
+			t = v
+		case []any:
+			if last && array {
+				m := map[string]any{}
+				t[key] = append(v, m)
+				return m, nil
+			}
+			var m map[string]any
+			if len(v) > 0 {
+				m, _ = v[len(v)-1].(map[string]any)
+			}
+			if m == nil {
+				return nil, p.errorf("key %q is not a table", strings.Join(keys[:i+1], "."))
+			}
+			// the tables of each element of the array are distinct
+			id += "[" + strconv.Itoa(len(v)-1) + "]"
+			t = m
+		default:
+			return nil, p.errorf("key %q is already defined", strings.Join(keys[:i+1], "."))
+		}
+	}
+
+	if !array {
+		if p.defined[id] {
+			return nil, p.errorf("table %q is already defined", strings.Join(keys, "."))
+		} else if p.defined == nil {
+			p.defined = map[string]bool{}
+		}
+		p.defined[id] = true
+	}
+	return t, nil
+}
+
+// parseKey parses bare, quoted and dotted keys
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			p.pos++
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case c == '\'':
+			p.pos++
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for c := p.peek(); c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'); c = p.peek() {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key, unexpected %q", p.peek())
+			}
+			key = string(p.src[start:p.pos])
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if !p.consume(".") {
+			return keys, nil
+		}
+		p.skipSpace()
+	}
+}
+
+// parseKeyValue parses "key = value" into the table
+func (p *tomlParser) parseKeyValue(table map[string]any) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.consume("=") {
+		return p.errorf("expected '=' after key %q", strings.Join(keys, "."))
+	}
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	t := table
+	for i, key := range keys[:len(keys)-1] {
+		switch v := t[key].(type) {
+		case nil:
+			m := map[string]any{}
+			t[key] = m
+			t = m
+		case map[string]any:
+			t = v
+		default:
+			return p.errorf("key %q is already defined", strings.Join(keys[:i+1], "."))
+		}
+	}
+	key := keys[len(keys)-1]
+	if _, exist := t[key]; exist {
+		return p.errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	t[key] = value
+	return nil
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		if p.consume(`"""`) {
+			return p.parseMultilineString(`"""`)
+		}
+		p.pos++
+		return p.parseBasicString()
+	case c == '\'':
+		if p.consume(`'''`) {
+			return p.parseMultilineString(`'''`)
+		}
+		p.pos++
+		return p.parseLiteralString()
+	case c == '[':
+		p.pos++
+		return p.parseArray()
+	case c == '{':
+		p.pos++
+		return p.parseInlineTable()
+	case p.consume("true"):
+		return true, nil
+	case p.consume("false"):
+		return false, nil
+	}
+
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n,]}#", p.peek()) < 0 {
+		p.pos++
+	}
+	// date and time separated by space (Ex. 1979-05-27 07:32:00Z)
+	if p.pos-start == 10 && tomlDateTime.Match(p.src[start:p.pos]) && p.pos+3 < len(p.src) &&
+		p.src[p.pos] == ' ' && p.src[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && strings.IndexByte(" \t\r\n,]}#", p.peek()) < 0 {
+			p.pos++
+		}
+	}
+	token := string(p.src[start:p.pos])
+	if token == "" {
+		return nil, p.errorf("expected a value, found %q", p.peek())
+	}
+
+	switch token {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+	if tomlDateTime.MatchString(token) {
+		return strings.Replace(token, " ", "T", 1), nil
+	}
+
+	number := strings.ReplaceAll(token, "_", "")
+	if len(number) > 2 && number[0] == '0' && strings.IndexByte("xob", number[1]) >= 0 {
+		if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+			return i, nil
+		}
+	} else if i, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return i, nil
+	} else if strings.ContainsAny(number, ".eE") {
+		if f, err := strconv.ParseFloat(number, 64); err == nil {
+			return f, nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("invalid value %q", token)
+}
+
+// parseBasicString parses the string after the opening quote
+func (p *tomlParser) parseBasicString() (string, error) {
+	var sb strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// parseLiteralString parses the string after the opening quote
+func (p *tomlParser) parseLiteralString() (string, error) {
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		if p.peek() == '\n' {
+			break
+		}
+		p.pos++
+	}
+	if !p.consume("'") {
+		return "", p.errorf("unterminated string")
+	}
+	return string(p.src[start : p.pos-1]), nil
+}
+
+// parseMultilineString parses the string after the opening delimiter, three double (basic) or single (literal) quotes
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	// a newline immediately following the opening delimiter is trimmed
+	if !p.consume("\n") {
+		p.consume("\r\n")
+	}
+
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		if p.consume(delim) {
+			// up to two quotes are allowed right before the closing delimiter
+			for i := 0; i < 2 && p.peek() == delim[0]; i++ {
+				sb.WriteByte(delim[0])
+				p.pos++
+			}
+			return sb.String(), nil
+		}
+
+		c := p.src[p.pos]
+		p.pos++
+		if c == '\\' && delim == `"""` {
+			// line ending backslash, trims the whitespaces and newlines
+			rest := p.pos
+			for rest < len(p.src) && (p.src[rest] == ' ' || p.src[rest] == '\t' || p.src[rest] == '\r') {
+				rest++
+			}
+			if rest < len(p.src) && p.src[rest] == '\n' {
+				p.pos = rest
+				for c := p.peek(); c == ' ' || c == '\t' || c == '\r' || c == '\n'; c = p.peek() {
+					p.pos++
+				}
+				continue
+			}
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// parseEscape parses the escape sequence after the backslash
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'e':
+		sb.WriteByte('\x1b')
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape %q", p.src[p.pos:p.pos+size])
+		}
+		p.pos += size
+		sb.WriteRune(rune(code))
+	default:
+		p.pos--
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// parseArray parses the array after the opening bracket
+func (p *tomlParser) parseArray() ([]any, error) {
+	list := []any{}
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or ']' in array, found %q", p.peek())
+		}
+	}
+}
+
+// parseInlineTable parses the table after the opening brace
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	table := map[string]any{}
+	p.skipSpace()
+	if p.consume("}") {
+		return table, nil
+	}
+	for {
+		p.skipSpace()
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or '}' in inline table, found %q", p.peek())
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XmlOptions options of CreateXmlUnmarshalFn
+type XmlOptions struct {
+	AttrPrefix string // prefix of the keys of attributes (Ex. "@" => "@id"), empty by default
+	TextKey    string // key of the text of elements with attributes or children, "value" by default
+}
+
+// XmlUnmarshal parses an XML document with the default XmlOptions, see CreateXmlUnmarshalFn
+//
+//	env.SetFileExt("xml", cfg.XmlUnmarshal)
+func XmlUnmarshal(content []byte) (map[string]any, error) {
+	return CreateXmlUnmarshalFn(XmlOptions{})(content)
+}
+
+// CreateXmlUnmarshalFn creates an UnmarshalFn for XML documents. The root element is dropped, its
+// children are the top level keys. Elements are mapped to objects, repeated sibling elements to
+// arrays, attributes to keys (with the prefix opts.AttrPrefix) and text content to strings. Elements
+// with attributes or children keep their text in the key opts.TextKey. An attribute with the same key
+// as a child element or as the text (Ex. <port value="80">8080</port>) is an error.
+//
+//	<config>
+//	  <server port="8080">localhost</server>   => server.port = "8080", server.value = "localhost"
+//	  <hosts>a</hosts><hosts>b</hosts>         => hosts = ["a", "b"]
+//	</config>
+func CreateXmlUnmarshalFn(opts XmlOptions) UnmarshalFn {
+	if opts.TextKey == "" {
+		opts.TextKey = "value"
+	}
+
+	return func(content []byte) (map[string]any, error) {
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return map[string]any{}, nil
+			} else if err != nil {
+				return nil, xmlError(dec, err)
+			}
+
+			if start, isStart := tok.(xml.StartElement); isStart {
+				value, err := xmlElement(dec, start, opts)
+				if err != nil {
+					return nil, xmlError(dec, err)
+				}
+				if obj, isObject := value.(map[string]any); isObject {
+					return obj, nil
+				}
+				return map[string]any{}, nil
+			}
+		}
+	}
+}
+
+// xmlElement reads the element until its end
+func xmlElement(dec *xml.Decoder, start xml.StartElement, opts XmlOptions) (any, error) {
+	obj := map[string]any{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		xmlAdd(obj, opts.AttrPrefix+attr.Name.Local, attr.Value)
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if xmlHasAttr(start, opts.AttrPrefix, t.Name.Local) {
+				return nil, fmt.Errorf("element <%s> conflicts with an attribute of <%s>, see XmlOptions.AttrPrefix", t.Name.Local, start.Name.Local)
+			}
+			child, err := xmlElement(dec, t, opts)
+			if err != nil {
+				return nil, err
+			}
+			xmlAdd(obj, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(obj) == 0 {
+				return s, nil
+			}
+			if _, exist := obj[opts.TextKey]; exist && s != "" {
+				return nil, fmt.Errorf("the text of <%s> conflicts with the key %q, see XmlOptions.TextKey", start.Name.Local, opts.TextKey)
+			} else if s != "" {
+				obj[opts.TextKey] = s
+			}
+			return obj, nil
+		}
+	}
+}
+
+// xmlHasAttr checks if the element has an attribute with the key
+func xmlHasAttr(start xml.StartElement, prefix, key string) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" && prefix+attr.Name.Local == key {
+			return true
+		}
+	}
+	return false
+}
+
+// xmlAdd sets the value of the key, repeated keys are converted to an array
+func xmlAdd(obj map[string]any, key string, value any) {
+	switch current := obj[key].(type) {
+	case nil:
+		obj[key] = value
+	case []any:
+		obj[key] = append(current, value)
+	default:
+		obj[key] = []any{current, value}
+	}
+}
+
+func xmlError(dec *xml.Decoder, err error) error {
+	line, column := dec.InputPos()
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &SyntaxError{Format: "xml", Line: syntaxErr.Line, Column: column, Msg: syntaxErr.Msg}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &SyntaxError{Format: "xml", Line: line, Column: column, Msg: err.Error()}
+}
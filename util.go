@@ -61,7 +61,7 @@ func Escape(key string) string {
 		segmentSize = strings.IndexByte(key, '.')
 		if segmentSize == -1 {
 			segmentSize = len(key)
-		} else if key[segmentSize-1] == '\\' {
+		} else if segmentSize > 0 && key[segmentSize-1] == '\\' {
 			if out != "" {
 				out += "\\."
 			}
@@ -95,7 +95,7 @@ func Segments(key string) (segments []string) {
 		segmentSize = strings.IndexByte(key, '.')
 		if segmentSize == -1 {
 			segmentSize = len(key)
-		} else if key[segmentSize-1] == '\\' {
+		} else if segmentSize > 0 && key[segmentSize-1] == '\\' {
 			segment += key[:segmentSize-1] + "."
 			key = key[segmentSize+1:]
 			continue
@@ -110,3 +110,47 @@ func Segments(key string) (segments []string) {
 	}
 	return
 }
+
+// MatchKey checks if the key matches the glob pattern. Patterns are compared by segment,
+// "*" matches any sequence of characters in a segment and "**" matches any number of segments.
+// (Ex. "**.password" matches "db.password" and "app.db.password", "db.*" matches "db.host")
+func MatchKey(pattern, key string) bool {
+	return matchSegments(Segments(pattern), Segments(key))
+}
+
+func matchSegments(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(key); i++ {
+				if matchSegments(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(key) == 0 || !matchSegment(pattern[0], key[0]) {
+			return false
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
+
+// matchSegment glob match with "*" wildcard only (brackets are part of array indexes)
+func matchSegment(pattern, segment string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == segment
+	}
+	prefix := pattern[:star]
+	if !strings.HasPrefix(segment, prefix) {
+		return false
+	}
+	rest := pattern[star+1:]
+	for i := len(prefix); i <= len(segment); i++ {
+		if matchSegment(rest, segment[i:]) {
+			return true
+		}
+	}
+	return false
+}
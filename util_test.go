@@ -45,3 +45,28 @@ func Test_Escape(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"db.password", "db.password", true},
+		{"db.*", "db.password", true},
+		{"db.*", "db.a.password", false},
+		{"**.password", "password", true},
+		{"**.password", "app.db.password", true},
+		{"**.secret*", "app.secretKey", true},
+		{"**.token", "app.tokens", false},
+		{"*.token", "servers[0].token", true},
+		{"app.**", "app.db.host", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"+"+tt.key, func(t *testing.T) {
+			if got := MatchKey(tt.pattern, tt.key); got != tt.want {
+				t.Errorf("MatchKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
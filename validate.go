@@ -0,0 +1,288 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RuleFn validates a config value. exist is false when the key is not defined,
+// in which case only the Required rule fails.
+type RuleFn func(value any, exist bool) error
+
+// Rule adds validation rules to the key, checked by Validate.
+//
+//	env.Rule("server.port", cfg.Required(), cfg.Range(1, 65535))
+func (c *Env) Rule(key string, rules ...RuleFn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.rules == nil {
+		c.rules = map[string][]RuleFn{}
+	}
+	c.rules[key] = append(c.rules[key], rules...)
+}
+
+// SetValidateOnLoad defines whether Load should run Validate after loading all sources.
+func (c *Env) SetValidateOnLoad(validate bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.validateOnLoad = validate
+}
+
+// Validate checks all the rules defined with Rule and returns a ValidationErrors
+// listing every violated key, or nil when the config is valid.
+func (c *Env) Validate() error {
+	c.mutex.RLock()
+	keys := make([]string, 0, len(c.rules))
+	rules := make(map[string][]RuleFn, len(c.rules))
+	for key, list := range c.rules {
+		keys = append(keys, key)
+		rules[key] = list
+	}
+	c.mutex.RUnlock()
+
+	sort.Strings(keys)
+
+	var errs ValidationErrors
+	for _, key := range keys {
+		value, exist := c.get(key)
+		errs = append(errs, validate(key, value, exist && value != nil, rules[key])...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validate(key string, value any, exist bool, rules []RuleFn) (errs ValidationErrors) {
+	for _, rule := range rules {
+		if err := rule(value, exist); err != nil {
+			errs = append(errs, &ValidationError{Key: key, Err: err})
+		}
+	}
+	return
+}
+
+// Required the key must exist and must not be an empty string
+func Required() RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return errors.New("is required")
+		} else if s, isString := value.(string); isString && strings.TrimSpace(s) == "" {
+			return errors.New("is required")
+		}
+		return nil
+	}
+}
+
+// Min the value must be a number greater than or equal to min
+func Min(min float64) RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if f, err := toFloat(value); err != nil {
+			return errors.New("must be a number")
+		} else if f < min {
+			return fmt.Errorf("must be greater than or equal to %v", min)
+		}
+		return nil
+	}
+}
+
+// Max the value must be a number less than or equal to max
+func Max(max float64) RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if f, err := toFloat(value); err != nil {
+			return errors.New("must be a number")
+		} else if f > max {
+			return fmt.Errorf("must be less than or equal to %v", max)
+		}
+		return nil
+	}
+}
+
+// Range the value must be a number between min and max (inclusive)
+func Range(min, max float64) RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if f, err := toFloat(value); err != nil {
+			return errors.New("must be a number")
+		} else if f < min || f > max {
+			return fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return nil
+	}
+}
+
+// OneOf the value must be one of the options
+func OneOf(options ...any) RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		}
+		s := fmt.Sprint(value)
+		for _, o := range options {
+			if fmt.Sprint(o) == s {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", options)
+	}
+}
+
+// Match the value must match the regular expression. Panics if the expression cannot be parsed.
+func Match(pattern string) RuleFn {
+	re := regexp.MustCompile(pattern)
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if !re.MatchString(fmt.Sprint(value)) {
+			return fmt.Errorf("must match %q", pattern)
+		}
+		return nil
+	}
+}
+
+// URL the value must be an absolute URL (scheme and host)
+func URL() RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if u, err := url.Parse(fmt.Sprint(value)); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be a valid URL")
+		}
+		return nil
+	}
+}
+
+// HostPort the value must be in the "host:port" format
+func HostPort() RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if _, port, err := net.SplitHostPort(fmt.Sprint(value)); err != nil {
+			return errors.New("must be in the host:port format")
+		} else if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return errors.New("must be in the host:port format")
+		}
+		return nil
+	}
+}
+
+// DurationRange the value must be a duration between min and max (inclusive), max <= 0 means no upper limit
+func DurationRange(min, max time.Duration) RuleFn {
+	return func(value any, exist bool) error {
+		if !exist {
+			return nil
+		} else if d, err := time.ParseDuration(fmt.Sprint(value)); err != nil {
+			return errors.New("must be a duration")
+		} else if d < min {
+			return fmt.Errorf("must be greater than or equal to %v", min)
+		} else if max > 0 && d > max {
+			return fmt.Errorf("must be less than or equal to %v", max)
+		}
+		return nil
+	}
+}
+
+// parseRules converts the content of the `validate` struct tag into rules.
+//
+// Supported: required, min=N, max=N, oneof=a b c, regex=EXPR, url, hostport, mindur=D, maxdur=D. The regex
+// rule takes the rest of the tag, commas included (Ex. "required,regex=^[a-z]{1,3}$").
+func parseRules(tag string) ([]RuleFn, error) {
+	var (
+		rules          []RuleFn
+		minDur, maxDur time.Duration
+		hasDur         bool
+	)
+	for _, item := range splitRules(tag) {
+		name, arg, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch name {
+		case "":
+			continue
+		case "required":
+			rules = append(rules, Required())
+		case "url":
+			rules = append(rules, URL())
+		case "hostport":
+			rules = append(rules, HostPort())
+		case "oneof":
+			var options []any
+			for _, o := range strings.Fields(arg) {
+				options = append(options, o)
+			}
+			rules = append(rules, OneOf(options...))
+		case "regex":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, Match(re.String()))
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", name, err)
+			}
+			if name == "min" {
+				rules = append(rules, Min(n))
+			} else {
+				rules = append(rules, Max(n))
+			}
+		case "mindur", "maxdur":
+			d, err := time.ParseDuration(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", name, err)
+			}
+			hasDur = true
+			if name == "mindur" {
+				minDur = d
+			} else {
+				maxDur = d
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", name)
+		}
+	}
+	if hasDur {
+		rules = append(rules, DurationRange(minDur, maxDur))
+	}
+	return rules, nil
+}
+
+// splitRules the items of the `validate` tag, separated by commas, a regex rule is the last item
+func splitRules(tag string) []string {
+	var items []string
+	for tag != "" {
+		item, rest, _ := strings.Cut(tag, ",")
+		if strings.HasPrefix(strings.TrimSpace(item), "regex=") {
+			item, rest = tag, ""
+		}
+		items = append(items, item)
+		tag = rest
+	}
+	return items
+}
+
+// validateField checks the rules of the `validate` tag during Bind
+func validateField(key string, field reflect.StructField, value any, exist bool) (ValidationErrors, error) {
+	tag, hasTag := field.Tag.Lookup("validate")
+	if !hasTag {
+		return nil, nil
+	}
+	rules, err := parseRules(tag)
+	if err != nil {
+		return nil, fmt.Errorf("cfg: invalid validate tag on field %s: %w", field.Name, err)
+	}
+	return validate(key, value, exist && value != nil, rules), nil
+}
@@ -0,0 +1,113 @@
+package cfg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnv_Validate(t *testing.T) {
+	env := New(O{
+		"server": O{
+			"port":    "80a",
+			"addr":    "localhost",
+			"mode":    "debug",
+			"url":     "example.com",
+			"timeout": "2m",
+			"name":    "api-01",
+		},
+	})
+
+	env.Rule("server.host", Required())
+	env.Rule("server.port", Required(), Range(1, 65535))
+	env.Rule("server.addr", HostPort())
+	env.Rule("server.mode", OneOf("dev", "prod"))
+	env.Rule("server.url", URL())
+	env.Rule("server.timeout", DurationRange(time.Second, time.Minute))
+	env.Rule("server.name", Match("^api-[0-9]+$"))
+	env.Rule("server.missing", Min(1), Max(2))
+
+	err := env.Validate()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Key)
+	}
+	want := []string{"server.addr", "server.host", "server.mode", "server.port", "server.timeout", "server.url"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+
+	env.LoadObject(O{
+		"server": O{
+			"host":    "localhost",
+			"port":    8080,
+			"addr":    "localhost:8080",
+			"mode":    "dev",
+			"url":     "https://example.com",
+			"timeout": "30s",
+		},
+	})
+	if err = env.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestEnv_Bind_Validate(t *testing.T) {
+	env := New(O{"server": O{"port": 80000, "mode": "test", "code": "abcd", "zone": "ab"}})
+
+	var server struct {
+		Host    string        `cfg:"host" validate:"required"`
+		Port    int           `cfg:"port" validate:"min=1,max=65535"`
+		Mode    string        `cfg:"mode" validate:"oneof=dev prod"`
+		Timeout time.Duration `cfg:"timeout" default:"2s" validate:"mindur=1s,maxdur=1m"`
+		Code    string        `cfg:"code" validate:"required,regex=^[a-z]{1,3}$"`
+		Zone    string        `cfg:"zone" validate:"regex=^[a-z]{1,3}$"`
+	}
+
+	err := env.Bind("server", &server)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Bind() error = %v, want ValidationErrors", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Key)
+	}
+	want := []string{"server.host", "server.port", "server.mode", "server.code"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bind() = %v, want %v", got, want)
+	}
+
+	// the errors of the elements are collected
+	env = New(O{
+		"servers": []any{O{"port": 0}, O{"port": 80}, O{"port": 70000}},
+		"named":   O{"a": O{"port": 0}, "b": O{"port": 80}},
+	})
+	type port struct {
+		Port int `cfg:"port" validate:"min=1,max=65535"`
+	}
+	var bound struct {
+		Servers []port          `cfg:"servers"`
+		Named   map[string]port `cfg:"named"`
+	}
+	if err = env.Unmarshal(&bound); !errors.As(err, &errs) {
+		t.Fatalf("Unmarshal() error = %v, want ValidationErrors", err)
+	}
+	got = nil
+	for _, e := range errs {
+		got = append(got, e.Key)
+	}
+	want = []string{"servers[0].port", "servers[2].port", "named.a.port"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v", got, want)
+	}
+}
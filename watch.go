@@ -0,0 +1,167 @@
+package cfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var errWatchUnsupported = errors.New("cfg: file system notifications not supported")
+
+// SetWatchInterval defines the polling interval used by Watch (default 2s).
+func (c *Env) SetWatchInterval(interval time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.watchInterval = interval
+}
+
+// Reload rebuilds the config with the same precedence as Load, starting from the state
+// prior to the Load call (defaults and values set before it). The new config is only
+// swapped in when all sources are loaded successfully, otherwise the last good config
+// is kept and the error is returned.
+func (c *Env) Reload() error {
+	c.mutex.RLock()
+	base := c.base
+	rules := c.rules
+	order := c.order
+	validateOnLoad := c.validateOnLoad
+	c.mutex.RUnlock()
+
+	if base == nil {
+		return errors.New("cfg: Reload called before Load")
+	}
+
+	n := c.derive()
+	n.root = base.Clone()
+	n.order = order
+	n.rules = rules
+	n.validateOnLoad = validateOnLoad
+	if err := n.load(); err != nil {
+		return err
+	}
+
+	c.mutate(SourceReload, func() {
+		c.root, c.expanded = n.root, true
+		if n.order > c.order {
+			// the origins of the new config are after the current ones
+			c.order = n.order
+		}
+	})
+	return nil
+}
+
+// Watch monitors the config files, profile files and dotenv files (see SetDotEnvFiles) for changes, calling Reload
+// when any of them is created, modified or removed. Blocks until ctx is done.
+//
+// When the FileSystem is a local directory (http.Dir) and the platform supports it, file
+// system notifications (inotify) are used, otherwise the files are polled (see SetWatchInterval).
+// Reload errors are logged and the last good config is kept.
+func (c *Env) Watch(ctx context.Context) error {
+	c.mutex.RLock()
+	loaded := c.base != nil
+	fs := c.fs
+	c.mutex.RUnlock()
+
+	if !loaded {
+		return errors.New("cfg: Watch called before Load")
+	}
+
+	state := c.watchState()
+
+	if dir, isDir := fs.(http.Dir); isDir {
+		if err := c.watchNotify(ctx, string(dir), state); !errors.Is(err, errWatchUnsupported) {
+			return err
+		}
+	}
+
+	return c.watchPoll(ctx, state)
+}
+
+func (c *Env) watchPoll(ctx context.Context, state map[string]string) error {
+	c.mutex.RLock()
+	interval := c.watchInterval
+	c.mutex.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			state = c.reloadIfChanged(state)
+		}
+	}
+}
+
+// reloadIfChanged compares the current state of the files with the previous one, calling Reload on changes
+func (c *Env) reloadIfChanged(state map[string]string) map[string]string {
+	current := c.watchState()
+	changed := len(current) != len(state)
+	for filepath, signature := range current {
+		if state[filepath] != signature {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return state
+	}
+
+	slog.Info("[cfg] config files changed, reloading.")
+	if err := c.Reload(); err != nil {
+		slog.Error("[cfg] could not reload config, keeping the last good config.", slog.Any("error", c.redactError(err)))
+	}
+
+	// profiles can change after reload
+	return c.watchState()
+}
+
+// watchFiles all files resolved by Load (existing or not)
+func (c *Env) watchFiles() []string {
+	// loading settings, read under the lock
+	s := c.derive()
+	profiles := profileList(c.String(s.profileKey))
+
+	files := s.dotEnvPaths(profiles)
+	for _, filepath := range s.filePaths {
+		for _, ext := range s.extensions() {
+			files = append(files, filepath+"."+ext)
+		}
+	}
+	for _, profile := range profiles {
+		for _, filepath := range s.filePaths {
+			for _, ext := range s.extensions() {
+				files = append(files, filepath+"-"+profile+"."+ext)
+			}
+		}
+	}
+	return files
+}
+
+// watchState signature (modification time and size) of each existing file
+func (c *Env) watchState() map[string]string {
+	c.mutex.RLock()
+	fs := c.fs
+	c.mutex.RUnlock()
+
+	state := map[string]string{}
+	if fs == nil {
+		return state
+	}
+	for _, filepath := range c.watchFiles() {
+		file, err := fs.Open(filepath)
+		if err != nil {
+			continue
+		}
+		if info, err := file.Stat(); err == nil {
+			state[filepath] = fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+		}
+		file.Close()
+	}
+	return state
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package cfg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// watchNotify uses inotify to monitor the directories of the config files
+func (c *Env) watchNotify(ctx context.Context, root string, state map[string]string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return errWatchUnsupported
+	}
+
+	// non-blocking descriptor, Read is handled by the runtime poller and unblocked by Close
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		file.Close()
+	}()
+
+	watched := map[string]bool{}
+	addWatches := func() {
+		for _, name := range c.watchFiles() {
+			dir := filepath.Dir(filepath.Join(root, filepath.FromSlash(name)))
+			if !watched[dir] {
+				if _, err := syscall.InotifyAddWatch(fd, dir, inotifyMask); err == nil {
+					watched[dir] = true
+				}
+			}
+		}
+	}
+	addWatches()
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		// events are only a hint, reloadIfChanged compares the files signatures
+		if n > 0 {
+			state = c.reloadIfChanged(state)
+			addWatches()
+		}
+	}
+}
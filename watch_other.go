@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cfg
+
+import "context"
+
+// watchNotify file system notifications are only implemented for linux, Watch falls back to polling
+func (c *Env) watchNotify(ctx context.Context, root string, state map[string]string) error {
+	return errWatchUnsupported
+}
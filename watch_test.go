@@ -0,0 +1,153 @@
+package cfg
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEnv_Reload(t *testing.T) {
+	fs := fstest.MapFS{
+		"config.json": {Data: []byte(`{"app": {"name": "v1"}}`)},
+	}
+
+	env := New(O{"app": O{"name": "default", "port": 80}})
+	env.SetFileSystem(http.FS(fs))
+
+	if err := env.Reload(); err == nil {
+		t.Errorf("Reload() expected error before Load")
+	}
+	if err := env.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := env.String("app.name"); got != "v1" {
+		t.Errorf("String() = %v, want %v", got, "v1")
+	}
+
+	fs["config.json"] = &fstest.MapFile{Data: []byte(`{"app": {"name": "v2"}}`)}
+	if err := env.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := env.String("app.name"); got != "v2" {
+		t.Errorf("String() = %v, want %v", got, "v2")
+	}
+
+	// the values set after the reload are after the reloaded ones
+	env.Set("app.port", 8080)
+	if name, port := env.Origin("app.name"), env.Origin("app.port"); port[0].Order <= name[0].Order {
+		t.Errorf("Origin() order = %d, want > %d", port[0].Order, name[0].Order)
+	}
+	env.Set("app.port", 80)
+
+	// invalid file keeps the last good config
+	fs["config.json"] = &fstest.MapFile{Data: []byte(`{"app": `)}
+	if err := env.Reload(); err == nil {
+		t.Errorf("Reload() expected error")
+	}
+	if got := env.String("app.name"); got != "v2" {
+		t.Errorf("String() = %v, want %v", got, "v2")
+	}
+	if got := env.Int("app.port"); got != 80 {
+		t.Errorf("Int() = %v, want %v", got, 80)
+	}
+
+	// removed file restores defaults
+	delete(fs, "config.json")
+	if err := env.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := env.String("app.name"); got != "default" {
+		t.Errorf("String() = %v, want %v", got, "default")
+	}
+}
+
+func TestEnv_Reload_Settings(t *testing.T) {
+	fs := http.FS(fstest.MapFS{"config.json": {Data: []byte(`{"app": {"name": "v1"}}`)}})
+
+	env := New()
+	env.SetFileSystem(fs)
+	if err := env.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// the settings are changed while reloading (go test -race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			env.SetFileExt("toml", TomlUnmarshal)
+			env.SetFilePaths("config")
+			env.SetFileSystem(fs)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if err := env.Reload(); err != nil {
+			t.Fatalf("Reload() error = %v", err)
+		}
+	}
+	<-done
+
+	if got := env.String("app.name"); got != "v1" {
+		t.Errorf("String() = %v, want %v", got, "v1")
+	}
+
+	// nil restores the working directory
+	env.SetFileSystem(nil)
+	if _, isDir := env.fs.(http.Dir); !isDir || !env.fsDefault {
+		t.Errorf("SetFileSystem(nil) fs = %#v, want the working directory", env.fs)
+	}
+}
+
+func TestEnv_Watch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(file, []byte(`{"app": {"name": "v1"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := New()
+	env.SetFileSystem(http.Dir(dir))
+	env.SetWatchInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := env.Watch(ctx); err == nil {
+		t.Errorf("Watch() expected error before Load")
+	}
+	if err := env.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- env.Watch(ctx)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(file, []byte(`{"app": {"name": "v2 changed"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for env.String("app.name") != "v2 changed" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Watch() config not reloaded, got %v", env.String("app.name"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Watch() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Watch() did not stop")
+	}
+}